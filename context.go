@@ -0,0 +1,43 @@
+package errors
+
+import (
+	"context"
+	//lint:ignore faillint Custom errors package needs to import standard library errors.
+	"errors"
+)
+
+// WrapCtx is like [Wrapf], but also attaches the error of ctx to the chain when ctx is done,
+// so that [Is] reports whether the operation failed because of the cancellation or deadline of ctx:
+//
+//	errors.Is(errors.WrapCtx(ctx, err, "fetch"), context.Canceled)
+//
+// The cause set by [context.WithCancelCause] is attached too, when there is one.
+// The message is the one of the cause, or of the error of ctx if the cause is nil.
+// The error of ctx is not attached again if the cause already matches it.
+//
+// If both the cause and the error of ctx are nil, this method returns nil, see [SetStrictNilWrap].
+func WrapCtx(ctx context.Context, cause error, format string, args ...any) error {
+	// the error and the cause of ctx are compared separately, since joining them creates a new error
+	// that never matches a cause already carrying them.
+	var attached []error
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if !errors.Is(cause, ctxErr) {
+			attached = append(attached, ctxErr)
+		}
+		if ctxCause := context.Cause(ctx); ctxCause != nil && ctxCause != ctxErr && !errors.Is(cause, ctxCause) { //nolint:errorlint
+			attached = append(attached, ctxCause)
+		}
+	}
+	if len(attached) > 0 {
+		sentinel := attached[0]
+		if len(attached) > 1 {
+			sentinel = errors.Join(attached...)
+		}
+		if cause == nil {
+			cause = sentinel
+		} else {
+			cause = &tagged{err: cause, sentinel: sentinel}
+		}
+	}
+	return WrapfSkip(1, cause, format, args...)
+}
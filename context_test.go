@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestWrapCtx(t *testing.T) {
+	if err := WrapCtx(context.Background(), nil, wrapper); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := WrapCtx(context.Background(), ErrTest, wrapper); err.Error() != wrapper+": "+ErrTest.Error() || Is(err, context.Canceled) {
+		t.Fatalf("expected a plain wrap when ctx is not done, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := WrapCtx(ctx, ErrTest, "%s %d", wrapper, 1)
+	if !Is(err, context.Canceled) || !Is(err, ErrTest) {
+		t.Fatalf("expected both the cause and the error of ctx to match")
+	}
+	if err.Error() != "test_wrapper 1: global_defined_error" {
+		t.Fatalf("expected the message of the cause, got %v", err)
+	}
+	reg := regexp.MustCompile(`^test_wrapper 1\n> github\.com\/mawngo\/go-errors\.TestWrapCtx	.*\/go-errors\/context_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the stacktrace to point at the caller, got:\n%+v", err)
+	}
+
+	if err := WrapCtx(ctx, nil, wrapper); !Is(err, context.Canceled) || err.Error() != wrapper+": context canceled" {
+		t.Fatalf("expected the error of ctx to be wrapped, got %v", err)
+	}
+	if err := WrapCtx(ctx, context.Canceled, wrapper); Unwrap(err) != context.Canceled { //nolint:errorlint
+		t.Fatalf("expected the error of ctx not to be attached twice, got %v", Unwrap(err))
+	}
+
+	ctx, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(ErrTest)
+	if err := WrapCtx(ctx, Raw("other"), wrapper); !Is(err, context.Canceled) || !Is(err, ErrTest) {
+		t.Fatalf("expected the cause of ctx to be attached, got %v", err)
+	}
+	err = WrapCtx(ctx, WrapCtx(ctx, Raw("other"), wrapper), wrapper)
+	attached := 0
+	Walk(err, func(err error) bool {
+		if err == context.Canceled { //nolint:errorlint
+			attached++
+		}
+		return true
+	})
+	if attached != 1 {
+		t.Fatalf("expected the error of ctx not to be attached twice, got %d", attached)
+	}
+	if _, ok := Unwrap(err).(*base); !ok { //nolint:errorlint
+		t.Fatalf("expected a plain wrap of an error already carrying the error of ctx, got %T", Unwrap(err))
+	}
+}
+
+func TestWrapCtxTree(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	expected := "test_wrapper\nglobal_defined_error\n"
+	if out := FormatTree(WrapCtx(ctx, ErrTest, wrapper)); out != expected {
+		t.Fatalf("expected the error of ctx not to render as a branch, got:\n%v", out)
+	}
+}
+
+func TestWrapCtxMeta(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := WrapCtx(ctx, WithCode(WithHTTPStatus(Newf(msg), 404), "code"), wrapper)
+	if status, ok := HTTPStatus(err); !ok || status != 404 {
+		t.Fatalf("expected the status to survive WrapCtx, got %d, %v", status, ok)
+	}
+	if code, ok := Code(err); !ok || code != "code" {
+		t.Fatalf("expected the code to survive WrapCtx, got %q, %v", code, ok)
+	}
+	if code := ExitCode(WrapCtx(ctx, WithExitCode(Newf(msg), 3), wrapper)); code != 3 {
+		t.Fatalf("expected the exit code to survive WrapCtx, got %d", code)
+	}
+}
@@ -0,0 +1,98 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/gob"
+	//lint:ignore faillint Custom errors package needs to import standard library errors.
+	"errors"
+	"time"
+)
+
+// encodedError is the representation of an error of a chain encoded by Encode, from the outermost.
+type encodedError struct {
+	// Message is the message of this error only if it is an error of this package, or its full message otherwise.
+	Message string
+	// Base reports whether the error was created by this package, so it carries a stacktrace and can have a cause.
+	Base bool
+	// Frames contains the resolved frames of the stacktrace of the error.
+	Frames []Frame
+	// Truncated reports whether frames were not recorded because of MaxStackDepth.
+	Truncated bool
+	// Goroutine is the ID of the goroutine the stacktrace was captured in, see CaptureGoroutineID.
+	Goroutine int64
+	// CreatedAt is the time the error was created, see CaptureTime.
+	CreatedAt time.Time
+}
+
+// Encode serializes the error chain of err into a compact binary form, to transport errors between processes,
+// for example from a worker to its coordinator. See [Decode].
+// The messages and the resolved frames of the stacktraces of the errors created by this package are preserved,
+// since program counters are not portable across processes. The first error of the chain that is not
+// created by this package is encoded with its message only, and ends the chain.
+// The metadata and fields attached to the chain are not encoded.
+func Encode(err error) ([]byte, error) {
+	var chain []encodedError
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok {
+			err = v.err
+			continue
+		}
+		if t, ok := err.(*tagged); ok { //nolint:errorlint
+			err = t.err
+			continue
+		}
+		if p, ok := err.(*PanicError); ok { //nolint:errorlint
+			err = p.err
+			continue
+		}
+		e, ok := asBase(err)
+		if !ok {
+			chain = append(chain, encodedError{Message: err.Error()})
+			break
+		}
+		chain = append(chain, encodedError{
+			Message:   e.message(),
+			Base:      true,
+			Frames:    e.stack.frames(),
+			Truncated: e.stack.truncated,
+			Goroutine: e.stack.goroutine,
+			CreatedAt: e.stack.created,
+		})
+		err = e.err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(chain); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode reconstructs an error chain serialized by [Encode]. The decoded errors have the messages of the
+// original errors, and the "%+v" verb prints their original stacktraces.
+// Since the original error values are not available, the decoded chain does not match them with [Is] or [As].
+// It returns a nil error if the encoded error was nil, and an error if data cannot be decoded.
+func Decode(data []byte) (error, error) {
+	var chain []encodedError
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&chain); err != nil {
+		return nil, err
+	}
+	var err error
+	for i := len(chain) - 1; i >= 0; i-- {
+		e := chain[i]
+		if !e.Base {
+			err = errors.New(e.Message)
+			continue
+		}
+		err = &base{
+			info: e.Message,
+			stack: stacktrace{
+				truncated: e.Truncated,
+				goroutine: e.Goroutine,
+				created:   e.CreatedAt,
+				resolved:  e.Frames,
+			},
+			err: err,
+		}
+	}
+	return err, nil
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	for i, err := range []error{
+		Newf(msg),
+		Wrapf(WithCode(Wrapf(Newf(msg), wrapper), "code"), "outer"),
+		Wrapf(fmt.Errorf("std: %w", Newf(msg)), wrapper),
+		Wrapw(ErrTest, errSentinel, wrapper),
+		WrapfNoStack(ErrTest, wrapper),
+	} {
+		data, e := Encode(err)
+		if e != nil {
+			t.Fatalf("case %d: expected no error, got %v", i, e)
+		}
+		decoded, e := Decode(data)
+		if e != nil {
+			t.Fatalf("case %d: expected no error, got %v", i, e)
+		}
+		if decoded.Error() != err.Error() {
+			t.Fatalf("case %d: expected the message %q, got %q", i, err.Error(), decoded.Error())
+		}
+		frames, _ := StackTrace(err)
+		if decodedFrames, _ := StackTrace(decoded); len(decodedFrames) != len(frames) {
+			t.Fatalf("case %d: expected %d frames, got %d", i, len(frames), len(decodedFrames))
+		}
+	}
+
+	err := Wrapf(Wrapf(Newf(msg), wrapper), "outer")
+	data, _ := Encode(err)
+	decoded, _ := Decode(data)
+	if expected, out := fmt.Sprintf("%+v", err), fmt.Sprintf("%+v", decoded); out != expected {
+		t.Fatalf("expected the stacktraces to be reproduced, got:\n%v\nexpected:\n%v", out, expected)
+	}
+
+	data, _ = Encode(nil)
+	if decoded, e := Decode(data); decoded != nil || e != nil {
+		t.Fatalf("expected nil, got %v, %v", decoded, e)
+	}
+	if _, e := Decode([]byte("invalid")); e == nil {
+		t.Fatalf("expected an error for invalid data")
+	}
+}
+
+func TestDecodeStack(t *testing.T) {
+	err := Wrapf(Wrapf(ErrTest, wrapper), "outer")
+	data, _ := Encode(err)
+	decoded, _ := Decode(data)
+	again, _ := Decode(data)
+
+	if frames := GetFrames(decoded); len(frames) == 0 || frames[0] != GetFrames(err)[0] {
+		t.Fatalf("expected the decoded frames, got %v", frames)
+	}
+	if out := OneLineStack(decoded); out == "" || out != OneLineStack(err) {
+		t.Fatalf("expected the one line stack %q, got %q", OneLineStack(err), out)
+	}
+	if !StackContains(decoded, "TestDecodeStack") {
+		t.Fatalf("expected the decoded stacktrace to be searched")
+	}
+	if label := OriginLabel(decoded); label == "" || label != OriginLabel(err) {
+		t.Fatalf("expected the origin label %q, got %q", OriginLabel(err), label)
+	}
+	if frame, ok := Entry(decoded); !ok || frame != GetFrames(Unwrap(err))[0] {
+		t.Fatalf("expected the entry frame, got %v, %v", frame, ok)
+	}
+	if !SameOrigin(decoded, again, 0) || !Equal(decoded, again) {
+		t.Fatalf("expected the decoded stacktraces to be compared")
+	}
+	if WithStack(decoded) != decoded { //nolint:errorlint
+		t.Fatalf("expected the decoded stacktrace to be kept")
+	}
+	if frames := GetFrames(Flatten(decoded)); len(frames) != len(GetFrames(err)) {
+		t.Fatalf("expected the flattened error to keep the decoded stacktrace, got %d frames", len(frames))
+	}
+	if out := fmt.Sprintf("%#v", decoded); out != fmt.Sprintf("%#v", err) {
+		t.Fatalf("expected the frames to be counted, got %s", out)
+	}
+}
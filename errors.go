@@ -14,7 +14,13 @@
 	//lint:ignore faillint Custom errors package needs to import standard library errors.
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 // base is the fundamental struct that implements the error interface, and act as the backbone of this package.
@@ -25,6 +31,26 @@ type base struct {
 	stack stacktrace
 	// err is the actual error that is being wrapped with a stacktrace and message information.
 	err error
+	// sentinel is the error this error is a copy of, see Reanchor. It is nil for errors that are not copies.
+	sentinel *base
+	// pooled reports whether this error was created by NewfPooled and can be released to the pool.
+	pooled bool
+}
+
+// MessageJoiner composes the message of a wrapping error from its own message and the message of its cause.
+// The default composition is "info: cause", it can be replaced, for example, by a suffix style annotation:
+//
+//	errors.MessageJoiner = func(info, cause string) string {
+//		return cause + " (" + info + ")"
+//	}
+//
+// MessageJoiner should be set during program initialization, before errors are used concurrently.
+// A nil MessageJoiner uses the default composition.
+var MessageJoiner = joinMessage
+
+// joinMessage composes a message with the default composition of [MessageJoiner].
+func joinMessage(info, cause string) string {
+	return info + ": " + cause
 }
 
 // Error implements the error interface.
@@ -32,18 +58,45 @@ func (b *base) Error() string {
 	if b.err != nil {
 		e := b.err.Error()
 		if e == b.info {
-			return e
+			return b.message()
+		}
+		if MessageJoiner == nil {
+			return joinMessage(b.message(), e)
 		}
-		return b.info + ": " + e
+		return MessageJoiner(b.message(), e)
 	}
-	return b.info
+	return b.message()
 }
 
 // String implements the [fmt.Stringer] interface.
 // String returns an error message of this error only.
 // For a full error chain message use Error instead.
 func (b *base) String() string {
-	return b.info
+	return b.message()
+}
+
+// Redactor, when set, is applied to the message of each error created by this package when it is rendered,
+// by Error, the "%v" and "%+v" verbs, and the JSON, JSON Lines, tree and [slog] outputs,
+// for example to scrub tokens or personal data from the messages before they are logged.
+// The messages of errors not created by this package are not redacted, except as part of the message of
+// an error created by [Wrap] or [WithStack], which repeats them. Redactor is nil by default, and has no cost then.
+//
+// Redactor should be set during program initialization, before errors are used concurrently.
+var Redactor func(msg string) string
+
+// message returns the message of this error, redacted by Redactor.
+func (b *base) message() string {
+	if Redactor == nil {
+		return b.info
+	}
+	return Redactor(b.info)
+}
+
+// Is implements the errors.Is interface, so that a copy returned by [Reanchor] matches the error it was copied from.
+// Distinct errors never match, even if they have the same message.
+func (b *base) Is(target error) bool {
+	t, ok := target.(*base) //nolint:errorlint
+	return ok && b.sentinel != nil && b.sentinel == t
 }
 
 // Unwrap implements the error Unwrap interface.
@@ -51,28 +104,90 @@ func (b *base) Unwrap() error {
 	return b.err
 }
 
+// StackTrace returns a copy of the program counters of the stacktrace captured when this error was created.
+// They can be resolved into frames with [runtime.CallersFrames].
+func (b *base) StackTrace() []uintptr {
+	return append([]uintptr(nil), b.stack.pcs...)
+}
+
+// Frames returns the frames of the stacktrace captured when this error was created, from the innermost call site.
+// The program counters are resolved when Frames is called.
+func (b *base) Frames() []Frame {
+	return b.stack.frames()
+}
+
+// Message returns the message of this error only, without the messages of its cause.
+// It is intended for templates, such as error pages rendered with [html/template].
+func (b *base) Message() string {
+	return b.message()
+}
+
+// CauseMessage returns the message of the cause of this error, or an empty string if it has no cause.
+func (b *base) CauseMessage() string {
+	if b.err == nil {
+		return ""
+	}
+	return b.err.Error()
+}
+
+// StackLines returns the frames of the stacktrace of this error rendered like the "%+v" verb does,
+// one frame per element without the trailing newline, so that templates can range over them.
+// It returns nil if this error has no stacktrace.
+func (b *base) StackLines() []string {
+	format := FrameFormat
+	if format == nil {
+		format = formatFrame
+	}
+	var lines []string
+	for _, frame := range skipFrames(packageFrames(b.stack.trimmedFrames())) {
+		lines = append(lines, format(frame))
+	}
+	return lines
+}
+
 // Format implements the [fmt.Formatter] interface to support the formatting of an error chain with the "%+v" verb.
 // Whenever an error is printed with the %+v format verb, stacktrace info gets dumped to the output.
+// A precision limits the number of frames printed for each error of the chain, for example "%+.3v".
+// The "%#v" verb prints the Go-syntax like representation returned by GoString.
 func (b *base) Format(s fmt.State, verb rune) {
 	if verb == 'v' && s.Flag('+') {
-		_, _ = s.Write([]byte(formatErrorChain(b)))
+		_, _ = s.Write([]byte(formatErrorChainState(s, b)))
+		return
+	}
+	if verb == 'v' && s.Flag('#') {
+		_, _ = s.Write([]byte(b.GoString()))
 		return
 	}
 	_, _ = s.Write([]byte(b.Error()))
 }
 
+// GoString implements the [fmt.GoStringer] interface to provide a readable representation of the error chain
+// for debugging, such as:
+//
+//	errors.Wrapf(errors.Newf("not found") /* 3 frames */, "get user") /* 3 frames */
+func (b *base) GoString() string {
+	return goString(b, 0)
+}
+
 // Newf formats a message according to a format specifier and returns a new error with a stacktrace
 // with recent call frames.
 // Each call to Newf returns a distinct error value even if the text is
 // identical. An alternative of the stdlib errors.New function.
 func Newf(format string, args ...any) error {
+	return NewfSkip(1, format, args...)
+}
+
+// NewfSkip is like [Newf], but skips the given number of frames above its caller when capturing the stacktrace.
+// This is useful for helpers creating errors on behalf of their caller: calling NewfSkip with skip=1
+// from a helper makes the stacktrace start at the caller of the helper.
+func NewfSkip(skip int, format string, args ...any) error {
 	info := format
 	if len(args) > 0 {
 		info = fmt.Sprintf(format, args...)
 	}
 	return &base{
 		info:  info,
-		stack: newStackTrace(),
+		stack: newStackTraceSkip(skip, info),
 		err:   nil,
 	}
 }
@@ -85,7 +200,7 @@ func Newf(format string, args ...any) error {
 func New(message string) error {
 	return &base{
 		info:  message,
-		stack: newStackTrace(),
+		stack: newStackTrace(message),
 		err:   nil,
 	}
 }
@@ -93,9 +208,57 @@ func New(message string) error {
 // Wrapf returns a new error by formatting the error message with the supplied format specifier
 // and wrapping another error with a stacktrace containing recent call frames.
 //
-// If the cause is nil, this method returns nil.
+// If the cause is nil, this method returns nil, see [SetStrictNilWrap].
 func Wrapf(cause error, format string, args ...any) error {
+	return WrapfSkip(1, cause, format, args...)
+}
+
+// WrapfSkip is like [Wrapf], but skips the given number of frames above its caller when capturing the stacktrace.
+// This is useful for helpers wrapping errors on behalf of their caller: calling WrapfSkip with skip=1
+// from a helper makes the stacktrace start at the caller of the helper.
+func WrapfSkip(skip int, cause error, format string, args ...any) error {
+	if cause == nil {
+		if strictNilWrap.Load() {
+			nilWrapped(newStackTraceSkip(skip, errNilWrapped))
+		}
+		return nil
+	}
+	info := format
+	if len(args) > 0 {
+		info = fmt.Sprintf(format, args...)
+	}
+	return &base{
+		info:  info,
+		stack: newStackTraceSkip(skip, info),
+		err:   cause,
+	}
+}
+
+// Wrapif is like [Wrapf], but only wraps the cause if pred reports true for it, otherwise it returns the cause unchanged.
+// This adds context conditionally without an if block at each call site, for example:
+//
+//	return errors.Wrapif(err, func(err error) bool {
+//		return !errors.Is(err, context.Canceled)
+//	}, "fetch %s", url)
+//
+// If the cause is nil, this method returns nil without calling pred, see [SetStrictNilWrap].
+func Wrapif(cause error, pred func(err error) bool, format string, args ...any) error {
+	if cause != nil && !pred(cause) {
+		return cause
+	}
+	return WrapfSkip(1, cause, format, args...)
+}
+
+// WrapfNoStack is like [Wrapf], but does not capture a stacktrace: it only adds a message to the cause.
+// This is useful in hot paths where the cause already carries the relevant stacktrace.
+// The "%+v" verb prints only the message line of this error.
+//
+// If the cause is nil, this method returns nil, see [SetStrictNilWrap].
+func WrapfNoStack(cause error, format string, args ...any) error {
 	if cause == nil {
+		if strictNilWrap.Load() {
+			nilWrapped(newStackTrace(errNilWrapped))
+		}
 		return nil
 	}
 	info := format
@@ -104,27 +267,379 @@ func Wrapf(cause error, format string, args ...any) error {
 	}
 	return &base{
 		info:  info,
-		stack: newStackTrace(),
+		stack: stacktrace{},
+		err:   cause,
+	}
+}
+
+// Wrapw is like [Wrapf], but also tags the error with the given sentinel: the result matches
+// the sentinel with [Is] and [As], while the chain of the cause is still traversable.
+// This categorizes an error at the wrap site in one call, for example:
+//
+//	return errors.Wrapw(err, ErrNotFound, "get user %d", id)
+//
+// If the cause is nil, this method returns nil, see [SetStrictNilWrap].
+// If the sentinel is nil, it behaves like [Wrapf].
+func Wrapw(cause error, sentinel error, format string, args ...any) error {
+	if cause != nil && sentinel != nil {
+		cause = &tagged{err: cause, sentinel: sentinel}
+	}
+	return WrapfSkip(1, cause, format, args...)
+}
+
+// tagged exposes both a cause and a sentinel to the multi-unwrap of the standard library, see [Wrapw].
+// Its message is the message of the cause.
+type tagged struct {
+	err      error
+	sentinel error
+}
+
+// Error implements the error interface.
+func (t *tagged) Error() string {
+	return t.err.Error()
+}
+
+// Unwrap implements the error Unwrap interface for multiple errors.
+func (t *tagged) Unwrap() []error {
+	return []error{t.err, t.sentinel}
+}
+
+// NewWrapf returns an error with two message layers sharing a single stacktrace containing recent call frames,
+// for the common pattern of creating an error and annotating it right away in the same function.
+// It is equivalent to, but cheaper and less noisy in the "%+v" output than:
+//
+//	errors.Wrapf(errors.Wrapf(cause, newMsg), wrapMsg)
+//
+// The stacktrace is carried by the inner layer, the outer layer only adds its message.
+// If the cause is nil, the inner layer is a new error, like [Newf] creates.
+func NewWrapf(cause error, newMsg, wrapMsg string) error {
+	inner := &base{
+		info:  newMsg,
+		stack: newStackTrace(newMsg),
+		err:   cause,
+	}
+	return &base{
+		info:  wrapMsg,
+		stack: stacktrace{},
+		err:   inner,
+	}
+}
+
+// Errorf is a drop-in replacement of [fmt.Errorf] that returns an error with a stacktrace
+// with recent call frames.
+// The message is formatted with [fmt.Errorf] semantics, and the errors of the %w verbs become the cause:
+// a single %w wraps its operand, multiple %w wrap all of them like the standard library,
+// so [Is] and [As] keep working on every operand.
+func Errorf(format string, args ...any) error {
+	wrapped := fmt.Errorf(format, args...)
+	info := wrapped.Error()
+	var cause error
+	switch wrapped.(type) {
+	case interface{ Unwrap() error }, interface{ Unwrap() []error }:
+		cause = wrapped
+	}
+	return &base{
+		info:  info,
+		stack: newStackTraceSkip(0, info),
 		err:   cause,
 	}
 }
 
+// Check returns nil if cond is true, otherwise it returns a new error formatted like [Newf]
+// with a stacktrace starting at the caller of Check. This reduces the boilerplate of guard clauses:
+//
+//	if err := errors.Check(x > 0, "x must be positive, got %d", x); err != nil {
+//		return err
+//	}
+//
+// Note that the arguments are evaluated even if cond is true.
+func Check(cond bool, format string, args ...any) error {
+	if cond {
+		return nil
+	}
+	return NewfSkip(1, format, args...)
+}
+
 // Wrap returns a new error by wrapping another error with a stacktrace containing recent call frames.
 //
-// If the cause is nil, this method returns nil.
+// If the cause is nil, this method returns nil, see [SetStrictNilWrap].
 //
 // Deprecated: It is recommended to add context msg to the error using [Wrapf] instead.
 func Wrap(cause error) error {
 	if cause == nil {
+		if strictNilWrap.Load() {
+			nilWrapped(newStackTrace(errNilWrapped))
+		}
 		return nil
 	}
 	return &base{
 		info:  cause.Error(),
-		stack: newStackTrace(),
+		stack: newStackTrace(cause.Error()),
+		err:   cause,
+	}
+}
+
+// WithStack returns err unchanged if an error of its chain already carries a stacktrace,
+// otherwise it wraps err with a stacktrace containing recent call frames, without adding a message.
+// This is useful to attach a stacktrace exactly once at the boundary with third-party libraries.
+//
+// If err is nil, this method returns nil.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	for e, depth := err, 0; e != nil && depth < MaxChainDepth; e, depth = unwrapChain(e), depth+1 {
+		if b, ok := asBase(e); ok && !b.stack.empty() {
+			return err
+		}
+	}
+	return &base{
+		info:  err.Error(),
+		stack: newStackTrace(err.Error()),
+		err:   err,
+	}
+}
+
+// Reanchor returns a copy of err whose stacktrace is replaced by one captured at the call site,
+// keeping its message and cause. The original error is not modified.
+// This is useful when re-returning a stored error, where the place it is returned from matters
+// more than the place it was created.
+// The copy matches the original error with [Is], so package-level errors created by this package,
+// such as with [NewSentinel] or [Newf], can be used as sentinels.
+// Errors created by [NewfPooled] cannot be used as sentinels: their copy does not match them.
+//
+// If err was not created by this package, it is wrapped like [Wrap] does.
+// If err is nil, this method returns nil.
+func Reanchor(err error) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := asBase(err)
+	if !ok {
+		return &base{
+			info:  err.Error(),
+			stack: newStackTrace(err.Error()),
+			err:   err,
+		}
+	}
+	c := *e
+	c.stack = newStackTrace(c.info)
+	if c.pooled {
+		// the original can be released and its memory reused by an unrelated error, so the copy
+		// does not link to it, and the copy is not released since its memory is not pooled.
+		c.pooled = false
+		return &c
+	}
+	if c.sentinel == nil {
+		c.sentinel = e
+	}
+	return &c
+}
+
+// WithMessage returns a copy of err with its message replaced by the formatted message,
+// keeping its stacktrace and cause, without adding a layer to the chain.
+// This is useful to sanitize internal messages at an API boundary while preserving the stacktrace.
+// The original error is not modified.
+//
+// If err was not created by this package, it is wrapped like [Wrapf] does.
+// If err is nil, this method returns nil.
+func WithMessage(err error, format string, args ...any) error {
+	if err == nil {
+		// unlike a wrap, replacing the message of nothing is not a mistake, see [SetStrictNilWrap].
+		return nil
+	}
+	e, ok := asBase(err)
+	if !ok {
+		return WrapfSkip(1, err, format, args...)
+	}
+	info := format
+	if len(args) > 0 {
+		info = fmt.Sprintf(format, args...)
+	}
+	c := *e
+	c.info = info
+	if c.pooled {
+		// the copy must not share the program counters of an error that can be released.
+		c.stack.pcs = slices.Clone(c.stack.pcs)
+		c.pooled = false
+	}
+	return &c
+}
+
+// Flatten returns a single error with the message of err and the outermost stacktrace of its chain,
+// without cause, for systems that cannot handle nested errors, such as at an API boundary.
+// The returned error does not match the chain of err with [Is] or [As].
+//
+// If err is nil, this method returns nil.
+func Flatten(err error) error {
+	if err == nil {
+		return nil
+	}
+	stack, _ := nearestStack(err)
+	// the program counters are cloned since they can belong to an error that can be released.
+	stack.pcs = slices.Clone(stack.pcs)
+	return &base{
+		info:  err.Error(),
+		stack: stack,
+		err:   nil,
+	}
+}
+
+// WrapCollected wraps each error of errs with the formatted message and the stacktrace at the same index
+// of stacks, then joins them. This preserves the origin of errors collected from other goroutines,
+// where the stacktrace of the collection point is not useful: each stack is usually captured
+// with [runtime.Callers] by the goroutine that produced the error.
+// An error without a corresponding stack is wrapped without stacktrace.
+//
+// Nil errors are discarded, WrapCollected returns nil if every error is nil.
+func WrapCollected(errs []error, stacks [][]uintptr, format string, args ...any) error {
+	info := format
+	if len(args) > 0 {
+		info = fmt.Sprintf(format, args...)
+	}
+	wrapped := make([]error, 0, len(errs))
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		var stack stacktrace
+		if i < len(stacks) {
+			stack.pcs = append(stack.pcs, stacks[i]...)
+		}
+		wrapped = append(wrapped, &base{
+			info:  info,
+			stack: stack,
+			err:   err,
+		})
+	}
+	return errors.Join(wrapped...)
+}
+
+// WrapAll wraps each error of errs with the formatted message like [Wrapf], then joins them.
+// Each wrapped error carries its own stacktrace pointing at the caller of WrapAll.
+//
+// Nil errors are discarded, WrapAll returns nil if every error is nil.
+func WrapAll(errs []error, format string, args ...any) error {
+	info := format
+	if len(args) > 0 {
+		info = fmt.Sprintf(format, args...)
+	}
+	wrapped := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		wrapped = append(wrapped, &base{
+			info:  info,
+			stack: newStackTrace(info),
+			err:   err,
+		})
+	}
+	return errors.Join(wrapped...)
+}
+
+// errNilWrapped is the message of the error reported when wrapping nil in strict mode.
+const errNilWrapped = "errors: wrapping a nil error"
+
+var (
+	// strictNilWrap holds the mode set by SetStrictNilWrap.
+	strictNilWrap atomic.Bool
+	// nilWrapHook holds the function set by SetNilWrapHook.
+	nilWrapHook atomic.Pointer[func(err error)]
+)
+
+// SetStrictNilWrap enables or disables the strict mode of [Wrap] and [Wrapf].
+// By default, wrapping a nil error silently returns nil, which can mask bugs where a nil was unexpected.
+// In strict mode, wrapping a nil error is reported to the hook set by [SetNilWrapHook],
+// or panics if no hook is set. The wrapping still returns nil when the hook returns.
+//
+// This is intended to catch accidental nil wrapping during development and tests.
+func SetStrictNilWrap(strict bool) {
+	strictNilWrap.Store(strict)
+}
+
+// SetNilWrapHook sets the function called when a nil error is wrapped in strict mode.
+// The hook receives an error with a stacktrace pointing at the wrapping call site.
+// Passing nil removes the hook, so strict mode panics instead.
+func SetNilWrapHook(hook func(err error)) {
+	if hook == nil {
+		nilWrapHook.Store(nil)
+		return
+	}
+	nilWrapHook.Store(&hook)
+}
+
+// nilWrapped reports a nil error being wrapped in strict mode.
+func nilWrapped(stack stacktrace) {
+	err := &base{
+		info:  errNilWrapped,
+		stack: stack,
+		err:   nil,
+	}
+	if hook := nilWrapHook.Load(); hook != nil {
+		(*hook)(err)
+		return
+	}
+	panic(&PanicError{err: err})
+}
+
+// WrapfFunc returns a new error wrapping cause with a stacktrace containing recent call frames,
+// using fn to compute the error message.
+// If fn fails, its error is joined with the cause as an additional branch of the wrapped error,
+// and the message returned by fn (if any) is still used.
+//
+// If the cause is nil, this method returns nil without calling fn.
+func WrapfFunc(cause error, fn func() (string, error)) error {
+	if cause == nil {
+		return nil
+	}
+	info, err := fn()
+	if err != nil {
+		cause = errors.Join(cause, err)
+	}
+	if info == "" {
+		info = cause.Error()
+	}
+	return &base{
+		info:  info,
+		stack: newStackTrace(info),
+		err:   cause,
+	}
+}
+
+// WrapfNew behaves like [Wrapf], but only wraps the first occurrence of cause recorded in seen.
+// When the same cause was already seen, it is returned unchanged.
+// This is useful to avoid adding duplicate context when a loop returns the same error repeatedly.
+//
+// Causes are identified by value when their dynamic type is comparable,
+// or by their type and message otherwise.
+// If the cause is nil, this method returns nil.
+func WrapfNew(seen *sync.Map, cause error, format string, args ...any) error {
+	if cause == nil {
+		return nil
+	}
+	if _, loaded := seen.LoadOrStore(identity(cause), struct{}{}); loaded {
+		return cause
+	}
+	info := format
+	if len(args) > 0 {
+		info = fmt.Sprintf(format, args...)
+	}
+	return &base{
+		info:  info,
+		stack: newStackTrace(info),
 		err:   cause,
 	}
 }
 
+// identity returns a map key identifying err.
+func identity(err error) any {
+	if reflect.ValueOf(err).Comparable() {
+		return err
+	}
+	return fmt.Sprintf("%T:%s", err, err.Error())
+}
+
 // Cause returns the result of repeatedly calling the Unwrap method on err, if err's
 // type implements an Unwrap method. Otherwise, Cause returns the last encountered error.
 // The difference between Unwrap and Cause is the first one performs unwrapping of one level
@@ -134,8 +649,50 @@ func Wrap(cause error) error {
 // actually can be sufficed through the errors.Is function. But considering some use cases
 // where we need to peel off all the external layers applied through errors.Wrap family,
 // it is useful (where external SDK doesn't use errors.Is internally).
+//
+// When the chain forks on an error joining multiple errors, such as returned by [Join],
+// the first non-nil joined error is considered the primary cause and is followed,
+// so Cause returns the cause of the first leaf found by a depth-first traversal, see [Leaves].
 func Cause(err error) error {
-	for err != nil {
+	return cause(err, nil)
+}
+
+// Origin returns the deepest error created by this package in the chain followed by [Cause],
+// which is usually the error created by [Newf] where the failure originated, with its stacktrace and message.
+// If the chain contains no error created by this package, Origin returns the same error as [Cause].
+func Origin(err error) error {
+	var origin error
+	last := cause(err, func(err error) {
+		if _, ok := asBase(err); ok {
+			origin = err
+		}
+	})
+	if origin == nil {
+		return last
+	}
+	return origin
+}
+
+// cause implements [Cause], calling visit, if not nil, with each error of the followed chain.
+func cause(err error, visit func(err error)) error {
+	for depth := 0; err != nil; depth++ {
+		if visit != nil {
+			visit(err)
+		}
+		if depth >= MaxChainDepth {
+			return err
+		}
+		if t, ok := err.(*tagged); ok { //nolint:errorlint
+			err = t.err
+			continue
+		}
+		if errs, ok := joinedErrors(err); ok {
+			if len(errs) == 0 {
+				return err
+			}
+			err = errs[0]
+			continue
+		}
 		e, ok := err.(interface {
 			Unwrap() error
 		})
@@ -147,15 +704,387 @@ func Cause(err error) error {
 	return nil
 }
 
+// Annotations returns the messages added by the wrappers of this package, from the outermost
+// toward the root cause. The root message itself is excluded: the walk stops at the first
+// error that is not created by this package, or at the error whose message equals the root's message.
+// Wrappers created by [Wrap] that only repeat the message of their cause are skipped.
+func Annotations(err error) []string {
+	if err == nil {
+		return nil
+	}
+	rootMsg := deepest(err).Error()
+
+	var annotations []string
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if inner, ok := unwrapLayer(err); ok {
+			err = inner
+			continue
+		}
+		e, ok := asBase(err)
+		if !ok || e.info == rootMsg {
+			break
+		}
+		if e.err == nil || e.info != e.err.Error() {
+			annotations = append(annotations, e.message())
+		}
+		err = e.err
+	}
+	return annotations
+}
+
+// Chain returns the message of each layer of the error chain, from the outermost toward the root cause,
+// without stacktraces: the message added by each error created by this package, and the full message
+// of each other error. Metadata and fields do not add layers, and wrappers created by [Wrap] that only
+// repeat the message of their cause are skipped. This is handy to render compact summaries, such as:
+//
+//	strings.Join(errors.Chain(err), " <- ")
+//
+// Joined errors are not traversed: an error joining multiple errors, such as returned by [Join],
+// is the last layer, with the message of all the joined errors.
+func Chain(err error) []string {
+	var layers []string
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok {
+			err = v.err
+			continue
+		}
+		if t, ok := err.(*tagged); ok { //nolint:errorlint
+			err = t.err
+			continue
+		}
+		if e, ok := asBase(err); ok {
+			if e.err == nil || e.info != e.err.Error() {
+				layers = append(layers, e.message())
+			}
+			err = e.err
+			continue
+		}
+		layers = append(layers, err.Error())
+		err = unwrapChain(err)
+	}
+	return layers
+}
+
+// SameRoot reports whether a and b stem from the same root error, regardless of the context
+// they were wrapped with. Root errors created by this package are compared by message and origin,
+// where the origin is the call site they were created at; other root errors are compared by identity,
+// or by type and message when they are not comparable.
+// This is useful to group alerts stemming from the same underlying failure.
+func SameRoot(a, b error) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ra, rb := deepest(a), deepest(b)
+	ea, okA := asBase(ra)
+	eb, okB := asBase(rb)
+	if okA != okB {
+		return false
+	}
+	if !okA {
+		return identity(ra) == identity(rb)
+	}
+	if ea.info != eb.info || ea.stack.empty() || eb.stack.empty() {
+		return ea == eb
+	}
+	if len(ea.stack.pcs) == 0 || len(eb.stack.pcs) == 0 {
+		// a decoded stacktrace has no program counters, its top frame is compared instead.
+		return ea.stack.top() == eb.stack.top()
+	}
+	return ea.stack.pcs[0] == eb.stack.pcs[0]
+}
+
+// Walk performs a depth-first traversal of the error chain of err, calling fn for each error,
+// starting with err itself. Both Unwrap() error and Unwrap() []error, as produced by [Join], are followed,
+// so every leaf of joined errors is visited. The traversal stops early when fn returns false.
+func Walk(err error, fn func(err error) bool) {
+	walk(err, fn, 0)
+}
+
+// walk visits err and its children, and returns false if the traversal was stopped.
+func walk(err error, fn func(err error) bool, depth int) bool {
+	if err == nil || depth >= MaxChainDepth {
+		return true
+	}
+	if !fn(err) {
+		return false
+	}
+	switch e := err.(type) { //nolint:errorlint
+	case interface{ Unwrap() error }:
+		return walk(e.Unwrap(), fn, depth+1)
+	case interface{ Unwrap() []error }:
+		for _, child := range e.Unwrap() {
+			if !walk(child, fn, depth+1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Leaves returns the deepest errors of the chain of err, which have no further error to unwrap,
+// in the order of a [Walk] traversal. This retrieves the individual failures of joined errors,
+// even when the joined error is wrapped.
+// A chain without joined errors returns a single element, and a nil err returns nil.
+func Leaves(err error) []error {
+	var leaves []error
+	Walk(err, func(err error) bool {
+		switch e := err.(type) { //nolint:errorlint
+		case interface{ Unwrap() error }:
+			if e.Unwrap() != nil {
+				return true
+			}
+		case interface{ Unwrap() []error }:
+			if slices.ContainsFunc(e.Unwrap(), func(err error) bool { return err != nil }) {
+				return true
+			}
+		}
+		leaves = append(leaves, err)
+		return true
+	})
+	return leaves
+}
+
+// Equal reports whether a and b have the same message, regardless of their identity.
+// Stacktraces are intentionally excluded from the comparison, which makes Equal suitable
+// for assertions in tests where the errors are created at different call sites.
+// Two nil errors are equal.
+func Equal(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b //nolint:errorlint
+	}
+	return a.Error() == b.Error()
+}
+
+// EqualChain is a stricter [Equal] comparing each error of the chains of a and b, as traversed by [Walk]:
+// the messages added by this package, the attached metadata and fields, and the messages
+// of the other errors must be the same, in the same order.
+// Like Equal, stacktraces are intentionally excluded from the comparison.
+func EqualChain(a, b error) bool {
+	return slices.Equal(chainLayers(a), chainLayers(b))
+}
+
+// chainLayers describes each error of the chain of err, without stacktraces.
+func chainLayers(err error) []string {
+	var layers []string
+	Walk(err, func(err error) bool {
+		if v, ok := asValue(err); ok {
+			layers = append(layers, fmt.Sprintf("%s=%v", v.key, v.value))
+		} else if e, ok := asBase(err); ok {
+			layers = append(layers, e.info)
+		} else {
+			layers = append(layers, err.Error())
+		}
+		return true
+	})
+	return layers
+}
+
+// asBase reports whether err itself, not its chain, was created by this package.
+func asBase(err error) (*base, bool) {
+	e, ok := err.(*base) //nolint:errorlint
+	return e, ok
+}
+
+// unwrapChain returns the next error of the chain of err: the cause of an error created by [Wrapw],
+// whose sentinel is not part of the chain, or the result of the Unwrap() error method of err otherwise.
+func unwrapChain(err error) error {
+	if t, ok := err.(*tagged); ok { //nolint:errorlint
+		return t.err
+	}
+	return errors.Unwrap(err)
+}
+
+// findBase returns the first error created by this package in the chain of err, like [errors.As] does,
+// and the number of errors stepped over to reach it. Joined errors are not traversed, and at most
+// limit errors are stepped over, to guard against self-referential chains.
+func findBase(err error, limit int) (*base, int, bool) {
+	for steps := 0; err != nil && steps < limit; steps++ {
+		if e, ok := asBase(err); ok {
+			return e, steps, true
+		}
+		if _, ok := joinedErrors(err); ok {
+			break
+		}
+		err = unwrapChain(err)
+	}
+	return nil, 0, false
+}
+
+// unwrapLayer returns the error wrapped by err if err only decorates it without adding a message,
+// such as the metadata attached by [WithCode] or the errors created by [Wrapw] and [AsTemporary].
+func unwrapLayer(err error) (error, bool) {
+	switch e := err.(type) { //nolint:errorlint
+	case *withValue:
+		return e.err, true
+	case *tagged:
+		return e.err, true
+	case *temporary:
+		return e.err, true
+	case *PanicError:
+		return e.err, true
+	}
+	return nil, false
+}
+
+// deepest returns the last non-nil error of the chain obtained by repeatedly calling Unwrap,
+// following the cause of the errors created by [Wrapw].
+// Unlike [Cause], it never returns nil for a non-nil err.
+func deepest(err error) error {
+	for depth := 0; depth < MaxChainDepth; depth++ {
+		next := unwrapChain(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+	return err
+}
+
+// MaxChainDepth is the maximum number of errors of a chain that are traversed, to guard against
+// accidental unbounded wrapping and self-referential chains: the "%+v" verb stops after MaxChainDepth errors
+// with a "... (chain truncated)" marker, and the functions walking the chain, such as [Cause], stop there.
+//
+// MaxChainDepth should be set during program initialization, before errors are used concurrently.
+var MaxChainDepth = 100
+
+// maxFormatBytes holds the limit set by SetMaxFormatBytes.
+var maxFormatBytes atomic.Int64
+
+// SetMaxFormatBytes limits the size of the output of the "%+v" verb to n bytes.
+// Longer output is truncated and suffixed with "... (truncated)", which protects log pipelines
+// from pathological errors, such as deep chains, huge messages or big stacktraces.
+// A value of zero or less removes the limit, which is the default.
+func SetMaxFormatBytes(n int) {
+	maxFormatBytes.Store(int64(n))
+}
+
+// truncateFormat truncates the formatted output according to SetMaxFormatBytes.
+func truncateFormat(s string) string {
+	limit := maxFormatBytes.Load()
+	if limit <= 0 || int64(len(s)) <= limit {
+		return s
+	}
+	n := int(limit)
+	// avoid cutting a multibyte character in half.
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "... (truncated)"
+}
+
+// DeduplicateStacks controls whether the "%+v" verb collapses the frames that a stacktrace shares with
+// the stacktrace printed above it, which happens when an error is wrapped several times in the same function.
+// When enabled, only the divergent frames are printed, followed by a "... same as above" marker.
+var DeduplicateStacks = false
+
+// goString returns the Go-syntax like representation of an error chain.
+// depth is the number of errors of the chain already represented.
+func goString(err error, depth int) string {
+	if depth >= MaxChainDepth {
+		return "..."
+	}
+	if v, ok := asValue(err); ok {
+		if v.field {
+			return fmt.Sprintf("errors.WithField(%s, %q, %#v)", goString(v.err, depth+1), string(v.key), v.value)
+		}
+		return fmt.Sprintf("errors.withMeta(%s, %q, %#v)", goString(v.err, depth+1), string(v.key), v.value)
+	}
+	e, ok := asBase(err)
+	if !ok {
+		return fmt.Sprintf("%#v", err)
+	}
+	frames := fmt.Sprintf(" /* %d frames */", e.stack.len())
+	if e.stack.truncated {
+		frames = fmt.Sprintf(" /* %d+ frames */", e.stack.len())
+	}
+	if e.err == nil {
+		return fmt.Sprintf("errors.Newf(%q)%s", e.message(), frames)
+	}
+	if t, ok := e.err.(*tagged); ok { //nolint:errorlint
+		return fmt.Sprintf("errors.Wrapw(%s, %s, %q)%s", goString(t.err, depth+1), goString(t.sentinel, depth+1), e.message(), frames)
+	}
+	return fmt.Sprintf("errors.Wrapf(%s, %q)%s", goString(e.err, depth+1), e.message(), frames)
+}
+
 // formatErrorChain formats an error chain.
 func formatErrorChain(err error) string {
 	var buf strings.Builder
-	for err != nil {
-		var e *base
-		if errors.As(err, &e) {
-			buf.WriteString(e.info)
+	writeErrorChain(&buf, err, FrameFormat, -1, 0)
+	return truncateFormat(buf.String())
+}
+
+// formatErrorChainState formats an error chain for the "%+v" verb of the state s:
+// a precision, as in "%+.3v", limits the number of frames printed for each error of the chain.
+func formatErrorChainState(s fmt.State, err error) string {
+	maxFrames, ok := s.Precision()
+	if !ok {
+		return formatErrorChain(err)
+	}
+	var buf strings.Builder
+	writeErrorChain(&buf, err, FrameFormat, maxFrames, 0)
+	return truncateFormat(buf.String())
+}
+
+// joinedIndent is the indentation of the chains of joined errors under their parent in the "%+v" output.
+const joinedIndent = "    "
+
+// writeErrorChain writes the formatted error chain of err to buf, rendering each frame with format,
+// and at most maxFrames frames for each error if maxFrames is not negative.
+// depth is the number of errors of the chain already written.
+// Each error joined by a multi-unwrap error has its chain written in turn, indented by joinedIndent.
+func writeErrorChain(buf io.StringWriter, err error, format func(frame Frame) string, maxFrames int, depth int) {
+	var prev stacktrace
+	for ; err != nil; depth++ {
+		if depth >= MaxChainDepth {
+			buf.WriteString("... (chain truncated)\n")
+			return
+		}
+		if v, ok := asValue(err); ok {
+			buf.WriteString(string(v.key))
+			buf.WriteString("=")
+			buf.WriteString(fmt.Sprint(v.value))
 			buf.WriteString("\n")
-			buf.WriteString(fmt.Sprintf("%v", e.stack))
+			err = v.err
+			continue
+		}
+		if t, ok := err.(*tagged); ok { //nolint:errorlint
+			err = t.err
+			continue
+		}
+		if p, ok := err.(*PanicError); ok { //nolint:errorlint
+			err = p.err
+			continue
+		}
+		if errs, ok := joinedErrors(err); ok {
+			// the chain of each joined error is indented under its parent, so nested joins read as a tree.
+			for _, e := range errs {
+				var child strings.Builder
+				writeErrorChain(&child, e, format, maxFrames, depth+1)
+				for line := range strings.Lines(child.String()) {
+					buf.WriteString(joinedIndent)
+					buf.WriteString(line)
+				}
+			}
+			return
+		}
+		if e, steps, ok := findBase(err, MaxChainDepth-depth); ok {
+			depth += steps
+			info := e.message()
+			if errs, ok := joinedErrors(e.err); ok && e.info == joinedMessage(errs) {
+				// the message only repeats the messages of the joined errors, which are written below.
+				info = "joined errors"
+			}
+			buf.WriteString(info)
+			buf.WriteString("\n")
+			if n := e.stack.commonSuffix(prev); DeduplicateStacks && n > 0 {
+				buf.WriteString(stacktrace{pcs: e.stack.pcs[:len(e.stack.pcs)-n], goroutine: e.stack.goroutine}.render(format, maxFrames))
+				buf.WriteString("... same as above\n")
+			} else {
+				buf.WriteString(e.stack.render(format, maxFrames))
+			}
+			if len(e.stack.pcs) > 0 {
+				prev = e.stack
+			}
 			err = e.err
 		} else {
 			buf.WriteString(err.Error())
@@ -163,7 +1092,34 @@ func formatErrorChain(err error) string {
 			err = nil
 		}
 	}
-	return buf.String()
+}
+
+// joinedErrors returns the non-nil errors joined by err if it implements Unwrap() []error,
+// like the errors returned by [Join] do.
+func joinedErrors(err error) ([]error, bool) {
+	if _, ok := err.(*tagged); ok { //nolint:errorlint
+		return nil, false
+	}
+	joined, ok := err.(interface{ Unwrap() []error }) //nolint:errorlint
+	if !ok {
+		return nil, false
+	}
+	errs := make([]error, 0, len(joined.Unwrap()))
+	for _, e := range joined.Unwrap() {
+		if e != nil {
+			errs = append(errs, e)
+		}
+	}
+	return errs, true
+}
+
+// joinedMessage returns the message of errs as joined by [Join].
+func joinedMessage(errs []error) string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
 }
 
 // The functions `Is`, `As` & `Unwrap` provides a thin wrapper around the builtin errors
@@ -185,6 +1141,48 @@ func As(err error, target any) bool {
 	return errors.As(err, target)
 }
 
+// AsType is a generic [As]: it finds the first error in err's chain that matches the type T,
+// which can be a pointer or an interface type, and returns it.
+// It returns the zero value of T and false if no error matches.
+//
+//	if pathErr, ok := errors.AsType[*fs.PathError](err); ok {
+//		fmt.Println(pathErr.Path)
+//	}
+func AsType[T error](err error) (T, bool) {
+	var target T
+	ok := errors.As(err, &target)
+	return target, ok
+}
+
+// AllAs returns every error of the chain of err that is of type T, which can be a pointer or
+// an interface type, for example to collect each validation error of a joined group.
+// The errors are returned in the order of a [Walk] traversal: depth-first, with joined errors visited
+// in the order they were joined. Unlike [As], the As method of errors is not called.
+// It returns nil if no error matches.
+func AllAs[T error](err error) []T {
+	var matches []T
+	Walk(err, func(err error) bool {
+		if e, ok := err.(T); ok { //nolint:errorlint
+			matches = append(matches, e)
+		}
+		return true
+	})
+	return matches
+}
+
+// AsOneOf calls [errors.As] with each target in order, and returns the index of the first
+// target that matched, which was set to the matching error.
+// This is handy for dispatching over several known error types.
+// It returns -1 and false if no target matched.
+func AsOneOf(err error, targets ...any) (int, bool) {
+	for i, target := range targets {
+		if errors.As(err, target) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
 // Unwrap is a wrapper of built-in errors.Unwrap.
 // Unwrap returns the result of calling the Unwrap method on err, if err's type contains an Unwrap method
 // returning error. Otherwise, Unwrap returns nil.
@@ -205,6 +1203,43 @@ func Join(errs ...error) error {
 	return errors.Join(errs...)
 }
 
+// NewSentinel returns an error without stacktrace, intended for package-level sentinel errors.
+// Like [Raw], it is comparable by identity with [Is], but it is an error of this package:
+// it is printed like other errors of this package and the stacktrace is attached where it is
+// returned, by wrapping it with [Wrapf], [Wrap] or [WithStack].
+//
+// Unlike [Newf], NewSentinel does not capture the stacktrace of the package initialization,
+// which would be useless and misleading.
+//
+//	var ErrNotFound = errors.NewSentinel("not found")
+//
+//	func find() error {
+//		return errors.WithStack(ErrNotFound)
+//	}
+func NewSentinel(msg string) error {
+	return &base{
+		info:  msg,
+		stack: stacktrace{},
+		err:   nil,
+	}
+}
+
+// JoinStack is like [Join], but the returned error carries a stacktrace pointing at the call site,
+// where the errors were combined. Nil errors are discarded, JoinStack returns nil if every error is nil.
+// The message is the messages of the errors separated by newlines, like [Join].
+// The "%+v" verb prints the stacktrace of the call site, followed by the formatted chain of each error.
+func JoinStack(errs ...error) error {
+	joined := errors.Join(errs...)
+	if joined == nil {
+		return nil
+	}
+	return &base{
+		info:  joined.Error(),
+		stack: newStackTrace(joined.Error()),
+		err:   joined,
+	}
+}
+
 // Raw is a wrapper of built-in [errors.New].
 // Raw creates an error without stacktrace,
 // for defining error constant without having to import the go standard errors package.
@@ -214,6 +1249,53 @@ func Raw(msg string) error {
 	return errors.New(msg)
 }
 
+// interned holds the errors returned by Intern, by message.
+var interned sync.Map
+
+// Intern returns the canonical error for the given message, creating it on first use.
+// All calls with the same message return the same error value, so it can be compared with [Is]
+// or used as a map key, and repeated errors do not allocate.
+// Like [Raw], the error does not carry a stacktrace.
+//
+// Interned errors are kept for the lifetime of the program, so do not intern unbounded messages.
+func Intern(msg string) error {
+	if err, ok := interned.Load(msg); ok {
+		return err.(error)
+	}
+	err, _ := interned.LoadOrStore(msg, errors.New(msg))
+	return err.(error)
+}
+
+// registry holds the sentinels created by Register, by name.
+var registry = struct {
+	sync.RWMutex
+	errs map[string]error
+}{errs: make(map[string]error)}
+
+// Register creates a sentinel with the given message, like [NewSentinel], and stores it under name,
+// so that it can be retrieved with [Lookup], for example to map error categories to localized messages.
+// If a sentinel is already registered under name, Register returns it and msg is ignored.
+//
+//	var ErrNotFound = errors.Register("not_found", "not found")
+func Register(name, msg string) error {
+	registry.Lock()
+	defer registry.Unlock()
+	if err, ok := registry.errs[name]; ok {
+		return err
+	}
+	err := NewSentinel(msg)
+	registry.errs[name] = err
+	return err
+}
+
+// Lookup returns the sentinel registered under name by [Register].
+func Lookup(name string) (error, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	err, ok := registry.errs[name]
+	return err, ok
+}
+
 // ErrUnsupported is a wrapper of built-in [errors.ErrUnsupported]
 // [errors.ErrUnsupported] indicates that a requested operation cannot be performed,
 // because it is unsupported. For example, a call to [os.Link] when using a
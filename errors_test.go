@@ -7,9 +7,17 @@
 	//lint:ignore faillint Custom errors package tests need to import standard library errors.
 	stderrors "errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"unicode/utf8"
 )
 
 const msg = "test_error_message"
@@ -162,6 +170,23 @@ func TestCause(t *testing.T) {
 			err:      Wrapf(stderrors.New("std-error"), wrapper),
 			expected: "std-error",
 		},
+		// check joined errors
+		{
+			err:      Wrapf(Join(nil, stderrors.New("std-error"), stderrors.New("other")), wrapper),
+			expected: "std-error",
+		},
+		{
+			err:      Join(Wrapf(Join(stderrors.New("std-error"), err), wrapper), stderrors.New("other")),
+			expected: "std-error",
+		},
+		{
+			err:   Wrapf(Join(err, stderrors.New("other")), wrapper),
+			isNil: true,
+		},
+		{
+			err:      Wrapw(stderrors.New("std-error"), ErrTest, wrapper),
+			expected: "std-error",
+		},
 		{
 			err:   nil,
 			isNil: true,
@@ -182,6 +207,23 @@ func TestCause(t *testing.T) {
 	}
 }
 
+func TestOrigin(t *testing.T) {
+	origin := Newf(msg)
+	if Origin(Wrapf(WithCode(Wrapf(origin, wrapper), "code"), wrapper)) != origin { //nolint:errorlint
+		t.Fatalf("expected the deepest error of the package")
+	}
+	inner := Wrapf(ErrTest, wrapper)
+	if Origin(Wrapf(Join(inner, origin), wrapper)) != inner { //nolint:errorlint
+		t.Fatalf("expected the deepest error of the package along the first joined error")
+	}
+	if Origin(fmt.Errorf("%w", ErrTest)) != ErrTest { //nolint:errorlint
+		t.Fatalf("expected the cause without errors of the package")
+	}
+	if Origin(nil) != nil {
+		t.Fatalf("expected nil")
+	}
+}
+
 func TestErrorIs(t *testing.T) {
 	// test with base error that implements interface containing Unwrap method
 	err := Wrap(ErrTest)
@@ -198,3 +240,1394 @@ func TestErrorAs(t *testing.T) {
 		t.Fatalf("expected error to be assignable to base error")
 	}
 }
+
+func TestAnnotations(t *testing.T) {
+	for i, tc := range []struct {
+		err      error
+		expected []string
+	}{
+		{
+			err:      Wrapf(Wrapf(stderrors.New("std-error"), wrapper), "outer"),
+			expected: []string{"outer", wrapper},
+		},
+		{
+			err:      Wrap(Wrapf(Newf(msg), wrapper)),
+			expected: []string{wrapper},
+		},
+		{
+			err:      Wrap(ErrTest),
+			expected: nil,
+		},
+		{
+			err:      Wrapw(WithCode(Wrapf(stderrors.New("std-error"), wrapper), "code"), errSentinel, "outer"),
+			expected: []string{"outer", wrapper},
+		},
+		{
+			err:      Wrapw(ErrTest, errSentinel, "outer"),
+			expected: []string{"outer"},
+		},
+		{
+			err:      nil,
+			expected: nil,
+		},
+	} {
+		t.Run("TestCase"+strconv.Itoa(i), func(t *testing.T) {
+			annotations := Annotations(tc.err)
+			if fmt.Sprint(annotations) != fmt.Sprint(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, annotations)
+			}
+		})
+	}
+}
+
+func TestWrapfNew(t *testing.T) {
+	var seen sync.Map
+	first := WrapfNew(&seen, ErrTest, wrapper)
+	if first.Error() != wrapper+": "+ErrTest.Error() {
+		t.Fatalf("expected the first occurrence to be wrapped")
+	}
+	second := WrapfNew(&seen, ErrTest, wrapper)
+	if second != ErrTest { //nolint:errorlint
+		t.Fatalf("expected the second occurrence not to be wrapped, got %v", second)
+	}
+	other := WrapfNew(&seen, Newf(msg), wrapper)
+	if other.Error() != wrapper+": "+msg {
+		t.Fatalf("expected a different error to be wrapped")
+	}
+	if WrapfNew(&seen, nil, wrapper) != nil {
+		t.Fatalf("expected nil for nil cause")
+	}
+
+	err := valueError{value: []int{1}}
+	if _, ok := asBase(WrapfNew(&seen, err, wrapper)); !ok {
+		t.Fatalf("expected the first occurrence of an error holding an uncomparable value to be wrapped")
+	}
+	if _, ok := asBase(WrapfNew(&seen, err, wrapper)); ok {
+		t.Fatalf("expected the second occurrence of an error holding an uncomparable value not to be wrapped")
+	}
+	if !SameRoot(Wrapf(err, wrapper), err) || SameRoot(err, valueError{value: 1}) {
+		t.Fatalf("expected errors holding uncomparable values to be compared by message")
+	}
+}
+
+// valueError is a comparable error type that can hold an uncomparable value.
+type valueError struct {
+	value any
+}
+
+func (e valueError) Error() string { return fmt.Sprint(e.value) }
+
+func TestWrapfFunc(t *testing.T) {
+	err := WrapfFunc(ErrTest, func() (string, error) {
+		return wrapper, nil
+	})
+	if err.Error() != wrapper+": "+ErrTest.Error() {
+		t.Fatalf("expected message computed by fn, got %q", err.Error())
+	}
+
+	errLookup := Raw("lookup failed")
+	err = WrapfFunc(ErrTest, func() (string, error) {
+		return "", errLookup
+	})
+	if !stderrors.Is(err, ErrTest) || !stderrors.Is(err, errLookup) {
+		t.Fatalf("expected both the cause and the fn error to be joined")
+	}
+	var joined interface{ Unwrap() []error }
+	if !stderrors.As(Unwrap(err), &joined) || len(joined.Unwrap()) != 2 {
+		t.Fatalf("expected the cause to be a joined error with 2 branches")
+	}
+	if err.Error() != ErrTest.Error()+"\n"+errLookup.Error() {
+		t.Fatalf("expected joined message, got %q", err.Error())
+	}
+
+	if WrapfFunc(nil, func() (string, error) {
+		t.Fatalf("fn must not be called for a nil cause")
+		return "", nil
+	}) != nil {
+		t.Fatalf("expected nil for nil cause")
+	}
+}
+
+func TestEntry(t *testing.T) {
+	_, cause := os.Open("does-not-exist")
+	err := Wrapf(cause, "open config")
+	_, _, line, _ := runtime.Caller(0)
+	err = Wrapf(Wrapf(err, wrapper), "outer")
+
+	frame, ok := Entry(err)
+	if !ok {
+		t.Fatalf("expected an entry frame")
+	}
+	if frame.Function != "github.com/mawngo/go-errors.TestEntry" || frame.Line != line-1 {
+		t.Fatalf("expected the entry frame to be the wrap site, got %+v", frame)
+	}
+	if !strings.HasSuffix(frame.File, "go-errors/errors_test.go") {
+		t.Fatalf("expected the entry frame file to be the test file, got %v", frame.File)
+	}
+
+	if _, ok := Entry(Wrapf(Newf(msg), wrapper)); ok {
+		t.Fatalf("expected no entry frame when no foreign error is wrapped")
+	}
+	if _, ok := Entry(Wrapf(WithCode(Newf(msg), "code"), wrapper)); ok {
+		t.Fatalf("expected no entry frame when metadata is attached to an error of this package")
+	}
+	if _, ok := Entry(Wrapw(Newf(msg), errSentinel, wrapper)); ok {
+		t.Fatalf("expected no entry frame when an error of this package is wrapped with a sentinel")
+	}
+	if _, ok := Entry(Wrapf(WithCode(cause, "code"), wrapper)); !ok {
+		t.Fatalf("expected an entry frame when metadata is attached to a foreign error")
+	}
+	if _, ok := Entry(cause); ok {
+		t.Fatalf("expected no entry frame for a foreign error")
+	}
+}
+
+func TestSetStrictNilWrap(t *testing.T) {
+	var reported []error
+	SetNilWrapHook(func(err error) {
+		reported = append(reported, err)
+	})
+	defer SetNilWrapHook(nil)
+
+	if Wrap(nil) != nil || Wrapf(nil, wrapper) != nil || len(reported) != 0 {
+		t.Fatalf("expected lenient mode to return nil silently")
+	}
+
+	SetStrictNilWrap(true)
+	defer SetStrictNilWrap(false)
+
+	if Wrap(nil) != nil || Wrapf(nil, wrapper) != nil {
+		t.Fatalf("expected strict mode to still return nil")
+	}
+	if len(reported) != 2 {
+		t.Fatalf("expected strict mode to trigger the hook, got %d calls", len(reported))
+	}
+	reg := regexp.MustCompile(errNilWrapped + `[ \n]+> github\.com\/mawngo\/go-errors\.TestSetStrictNilWrap	.*\/go-errors\/errors_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", reported[0])) {
+		t.Fatalf("expected the reported error to point at the wrap call site, got %+v", reported[0])
+	}
+
+	SetNilWrapHook(nil)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected strict mode without hook to panic")
+		}
+	}()
+	_ = Wrap(nil)
+}
+
+func TestOriginLabel(t *testing.T) {
+	err := Newf(msg)
+	_, _, line, _ := runtime.Caller(0)
+	err = Wrapf(Wrapf(err, wrapper), wrapper)
+
+	expected := "go-errors.TestOriginLabel:" + strconv.Itoa(line-1)
+	if label := OriginLabel(err); label != expected {
+		t.Fatalf("expected %q, got %q", expected, label)
+	}
+	if label := OriginLabel(Wrapw(err, errSentinel, wrapper)); label != expected {
+		t.Fatalf("expected the origin below Wrapw %q, got %q", expected, label)
+	}
+	if label := OriginLabel(ErrTest); label != "" {
+		t.Fatalf("expected empty label for an error without stacktrace, got %q", label)
+	}
+}
+
+func storedError() error {
+	return Wrapf(ErrTest, wrapper)
+}
+
+func TestReanchor(t *testing.T) {
+	stored := storedError()
+	err := Reanchor(stored)
+
+	if err.Error() != stored.Error() {
+		t.Fatalf("expected the message to be preserved, got %q", err.Error())
+	}
+	if !stderrors.Is(err, ErrTest) {
+		t.Fatalf("expected the cause to be preserved")
+	}
+	reg := regexp.MustCompile(`^test_wrapper[ \n]+> github\.com\/mawngo\/go-errors\.TestReanchor	.*\/go-errors\/errors_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the stacktrace to point at the Reanchor call site, got %+v", err)
+	}
+	if !strings.Contains(fmt.Sprintf("%+v", stored), "go-errors.storedError") {
+		t.Fatalf("expected the original error to be left untouched")
+	}
+
+	reg = regexp.MustCompile(`^global_defined_error[ \n]+> github\.com\/mawngo\/go-errors\.TestReanchor	`)
+	if !reg.MatchString(fmt.Sprintf("%+v", Reanchor(ErrTest))) {
+		t.Fatalf("expected a foreign error to be wrapped at the Reanchor call site")
+	}
+	if Reanchor(nil) != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+func TestAsOneOf(t *testing.T) {
+	_, cause := os.Open("does-not-exist")
+	err := Wrapf(cause, wrapper)
+
+	var numErr *strconv.NumError
+	var pathErr *os.PathError
+	i, ok := AsOneOf(err, &numErr, &pathErr)
+	if !ok || i != 1 {
+		t.Fatalf("expected the second target to match, got %d, %v", i, ok)
+	}
+	if pathErr == nil || pathErr.Path != "does-not-exist" {
+		t.Fatalf("expected the matching target to be set")
+	}
+	if i, ok := AsOneOf(ErrTest, &numErr, &pathErr); ok || i != -1 {
+		t.Fatalf("expected no target to match, got %d, %v", i, ok)
+	}
+}
+
+func TestStacktraceOmitsInternalFrames(t *testing.T) {
+	var seen sync.Map
+	for name, err := range map[string]error{
+		"Newf":      Newf(msg),
+		"New":       New(msg),
+		"Wrapf":     Wrapf(ErrTest, wrapper),
+		"Wrap":      Wrap(ErrTest),
+		"WrapfNew":  WrapfNew(&seen, ErrTest, wrapper),
+		"WrapfFunc": WrapfFunc(ErrTest, func() (string, error) { return wrapper, nil }),
+		"Reanchor":  Reanchor(ErrTest),
+	} {
+		t.Run(name, func(t *testing.T) {
+			st, ok := nearestStack(err)
+			if !ok || len(st.pcs) == 0 {
+				t.Fatalf("expected a stacktrace")
+			}
+			frames := st.frames()
+			for _, frame := range frames {
+				if strings.Contains(frame.Function, "go-errors.newStackTrace") ||
+					strings.HasSuffix(frame.Function, "go-errors."+name) {
+					t.Fatalf("expected no internal frame, got %v", frame.Function)
+				}
+			}
+			if frames[0].Function != "github.com/mawngo/go-errors.TestStacktraceOmitsInternalFrames" {
+				t.Fatalf("expected the top frame to be the call site, got %v", frames[0].Function)
+			}
+		})
+	}
+}
+
+func TestSameRoot(t *testing.T) {
+	if !SameRoot(Wrapf(ErrTest, wrapper), Wrapf(Wrapf(ErrTest, "other"), wrapper)) {
+		t.Fatalf("expected errors wrapping the same sentinel to share a root")
+	}
+	if SameRoot(Wrapf(ErrTest, wrapper), Wrapf(ErrUnsupported, wrapper)) {
+		t.Fatalf("expected errors wrapping different sentinels not to share a root")
+	}
+
+	errs := make([]error, 0, 2)
+	for range 2 {
+		errs = append(errs, Newf(msg))
+	}
+	if !SameRoot(Wrapf(errs[0], wrapper), errs[1]) {
+		t.Fatalf("expected errors created at the same call site to share a root")
+	}
+	if SameRoot(errs[0], Newf(msg)) {
+		t.Fatalf("expected errors created at different call sites not to share a root")
+	}
+	if !SameRoot(Wrapw(ErrTest, errSentinel, "a"), Wrapw(ErrTest, errSentinel, "b")) {
+		t.Fatalf("expected errors wrapped with Wrapw to share the root of their cause")
+	}
+	if SameRoot(errs[0], nil) {
+		t.Fatalf("expected nil not to share a root")
+	}
+}
+
+func TestIntern(t *testing.T) {
+	err := Intern("interned")
+	if err != Intern("interned") { //nolint:errorlint
+		t.Fatalf("expected the same error value for the same message")
+	}
+	if err == Intern("other") { //nolint:errorlint
+		t.Fatalf("expected a different error value for a different message")
+	}
+	if err.Error() != "interned" {
+		t.Fatalf("expected the message to match, got %q", err.Error())
+	}
+	if !stderrors.Is(Wrapf(Intern("interned"), wrapper), err) {
+		t.Fatalf("expected interned errors to match with Is")
+	}
+	if _, ok := nearestStack(err); ok {
+		t.Fatalf("expected no stacktrace")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	err := Register("registered", "registered")
+	if found, ok := Lookup("registered"); !ok || found != err { //nolint:errorlint
+		t.Fatalf("expected the registered sentinel, got %v, %v", found, ok)
+	}
+	if Register("registered", "other") != err { //nolint:errorlint
+		t.Fatalf("expected the already registered sentinel")
+	}
+	if !Is(Wrapf(err, wrapper), err) || Is(Register("other", "registered"), err) {
+		t.Fatalf("expected registered sentinels to match by identity with Is")
+	}
+	if _, ok := Lookup("unknown"); ok {
+		t.Fatalf("expected no sentinel")
+	}
+}
+
+func collectWorkerA() ([]uintptr, error) {
+	pc := make([]uintptr, 16)
+	return pc[:runtime.Callers(1, pc)], ErrTest
+}
+
+func collectWorkerB() ([]uintptr, error) {
+	pc := make([]uintptr, 16)
+	return pc[:runtime.Callers(1, pc)], ErrUnsupported
+}
+
+func TestWrapCollected(t *testing.T) {
+	errs := make([]error, 3)
+	stacks := make([][]uintptr, 3)
+	var wg sync.WaitGroup
+	for i, worker := range []func() ([]uintptr, error){collectWorkerA, collectWorkerB} {
+		wg.Go(func() {
+			stacks[i], errs[i] = worker()
+		})
+	}
+	wg.Wait()
+
+	err := WrapCollected(errs, stacks, "worker %s", "failed")
+	if !stderrors.Is(err, ErrTest) || !stderrors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected every collected error to be joined")
+	}
+	var joined interface{ Unwrap() []error }
+	if !stderrors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Fatalf("expected nil errors to be discarded")
+	}
+	for i, branch := range joined.Unwrap() {
+		reg := regexp.MustCompile(`^worker failed[ \n]+> github\.com\/mawngo\/go-errors\.collectWorker` + string(rune('A'+i)) + `	`)
+		if !reg.MatchString(fmt.Sprintf("%+v", branch)) {
+			t.Fatalf("expected branch %d to render its provided stack, got:\n%+v", i, branch)
+		}
+	}
+	if WrapCollected(make([]error, 2), nil, wrapper) != nil {
+		t.Fatalf("expected nil when every error is nil")
+	}
+}
+
+func TestWrapAll(t *testing.T) {
+	err := WrapAll([]error{ErrTest, nil, ErrUnsupported}, "task %s", "failed")
+	var joined interface{ Unwrap() []error }
+	if !stderrors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Fatalf("expected nil errors to be discarded")
+	}
+	if !stderrors.Is(err, ErrTest) || !stderrors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected every error to be joined")
+	}
+	reg := regexp.MustCompile(`^task failed\n> github\.com\/mawngo\/go-errors\.TestWrapAll	.*\/go-errors\/errors_test\.go:\d+`)
+	for i, branch := range joined.Unwrap() {
+		if !reg.MatchString(fmt.Sprintf("%+v", branch)) {
+			t.Fatalf("expected branch %d to point at the call site, got:\n%+v", i, branch)
+		}
+	}
+	if WrapAll(make([]error, 2), wrapper) != nil {
+		t.Fatalf("expected nil when every error is nil")
+	}
+}
+
+func TestSetMaxFormatBytes(t *testing.T) {
+	SetMaxFormatBytes(64)
+	defer SetMaxFormatBytes(0)
+
+	err := Wrapf(Newf("%s", strings.Repeat("é", 1000)), wrapper)
+	out := fmt.Sprintf("%+v", err)
+	if !strings.HasSuffix(out, "... (truncated)") {
+		t.Fatalf("expected the output to be suffixed, got %q", out)
+	}
+	if len(out) > 64+len("... (truncated)") {
+		t.Fatalf("expected the output to be capped, got %d bytes", len(out))
+	}
+	if !utf8.ValidString(out) {
+		t.Fatalf("expected the output to remain valid UTF-8")
+	}
+	if out := fmt.Sprintf("%+v", &base{info: "short", err: ErrTest}); out != "short\nglobal_defined_error\n" {
+		t.Fatalf("expected short output not to be truncated, got %q", out)
+	}
+
+	SetMaxFormatBytes(0)
+	if out := fmt.Sprintf("%+v", err); strings.Contains(out, "truncated") {
+		t.Fatalf("expected no limit by default")
+	}
+}
+
+func TestStackTrace(t *testing.T) {
+	err := Wrapf(Newf(msg), wrapper)
+	_, _, line, _ := runtime.Caller(0)
+
+	frames, ok := StackTrace(Wrapf(WithRequestID(err, "req-1"), wrapper))
+	if !ok || len(frames) == 0 {
+		t.Fatalf("expected frames")
+	}
+	frames, _ = StackTrace(err)
+	if frames[0].Function != "github.com/mawngo/go-errors.TestStackTrace" || frames[0].Line != line-1 {
+		t.Fatalf("expected the first frame to be the call site, got %+v", frames[0])
+	}
+
+	var st interface{ StackTrace() []uintptr }
+	if !stderrors.As(err, &st) {
+		t.Fatalf("expected the error to expose its program counters")
+	}
+	pcs := st.StackTrace()
+	if len(pcs) != len(frames) {
+		t.Fatalf("expected %d program counters, got %d", len(frames), len(pcs))
+	}
+	frame, _ := runtime.CallersFrames(pcs).Next()
+	if frame.Function != frames[0].Function || frame.Line != frames[0].Line {
+		t.Fatalf("expected the program counters to resolve to the same frames, got %+v", frame)
+	}
+
+	if frames, ok := StackTrace(WrapfNoStack(err, wrapper)); !ok || !slices.Equal(frames, GetFrames(err)) {
+		t.Fatalf("expected the frames of the nearest stacktrace, got %+v", frames)
+	}
+	if frames, ok := StackTrace(NewWrapf(nil, msg, wrapper)); !ok || len(frames) == 0 {
+		t.Fatalf("expected the frames of the inner error")
+	}
+	if _, ok := StackTrace(ErrTest); ok {
+		t.Fatalf("expected no stacktrace for a foreign error")
+	}
+	if _, ok := StackTrace(nil); ok {
+		t.Fatalf("expected no stacktrace for nil")
+	}
+}
+
+func wrapHelper(err error) error {
+	return WrapfSkip(1, err, "helper: %s", wrapper)
+}
+
+func newHelper() error {
+	return NewfSkip(1, "helper: %s", msg)
+}
+
+func TestSkip(t *testing.T) {
+	for i, err := range []error{
+		wrapHelper(ErrTest),
+		newHelper(),
+		NewfSkip(0, msg),
+		WrapfSkip(0, ErrTest, wrapper),
+	} {
+		t.Run("TestCase"+strconv.Itoa(i), func(t *testing.T) {
+			reg := regexp.MustCompile(`^[^\n]+\n> github\.com\/mawngo\/go-errors\.TestSkip	.*\/go-errors\/errors_test\.go:\d+`)
+			if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+				t.Fatalf("expected the first frame to be the caller, got:\n%+v", err)
+			}
+		})
+	}
+	if WrapfSkip(1, nil, wrapper) != nil {
+		t.Fatalf("expected nil for nil cause")
+	}
+}
+
+func TestDeduplicateStacks(t *testing.T) {
+	DeduplicateStacks = true
+	defer func() {
+		DeduplicateStacks = false
+	}()
+
+	err := Newf(msg)
+	err = WithCode(Wrapf(err, wrapper), "code")
+	err = Wrapf(err, wrapper)
+	reg := regexp.MustCompile(`^test_wrapper
+> github\.com\/mawngo\/go-errors\.TestDeduplicateStacks	.*\/go-errors\/errors_test\.go:\d+
+> testing\.tRunner	[^\n]+
+> runtime\.goexit	[^\n]+
+code=code
+test_wrapper
+> github\.com\/mawngo\/go-errors\.TestDeduplicateStacks	.*\/go-errors\/errors_test\.go:\d+
+\.\.\. same as above
+test_error_message
+> github\.com\/mawngo\/go-errors\.TestDeduplicateStacks	.*\/go-errors\/errors_test\.go:\d+
+\.\.\. same as above
+$`)
+	if out := fmt.Sprintf("%+v", err); !reg.MatchString(out) {
+		t.Fatalf("expected the common frames to be collapsed, got:\n%v", out)
+	}
+
+	err = Wrapf(Wrapf(deepStack(2), wrapper), "outer")
+	out := fmt.Sprintf("%+v", err)
+	if strings.Count(out, "same as above") != 2 || strings.Count(out, "go-errors.deepStack") != 3 {
+		t.Fatalf("expected only the divergent frames to be printed, got:\n%v", out)
+	}
+
+	DeduplicateStacks = false
+	if out := fmt.Sprintf("%+v", err); strings.Contains(out, "same as above") {
+		t.Fatalf("expected the full stacks by default, got:\n%v", out)
+	}
+}
+
+func TestWithStack(t *testing.T) {
+	err := WithStack(ErrTest)
+	if err.Error() != ErrTest.Error() || !stderrors.Is(err, ErrTest) {
+		t.Fatalf("expected the message to be unchanged, got %q", err.Error())
+	}
+	reg := regexp.MustCompile(`^global_defined_error[ \n]+> github\.com\/mawngo\/go-errors\.TestWithStack	`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected a stacktrace at the call site, got:\n%+v", err)
+	}
+
+	for i, err := range []error{
+		err,
+		Newf(msg),
+		WithCode(Wrapf(ErrTest, wrapper), "code"),
+	} {
+		if WithStack(err) != err { //nolint:errorlint
+			t.Fatalf("case %d: expected an error carrying a stacktrace to be returned unchanged", i)
+		}
+	}
+	if WithStack(nil) != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+func TestGoString(t *testing.T) {
+	err := WithField(Wrapf(Newf(msg), wrapper), "user_id", 42)
+	reg := regexp.MustCompile(`^errors\.WithField\(errors\.Wrapf\(errors\.Newf\("test_error_message"\) /\* \d+ frames \*/, "test_wrapper"\) /\* \d+ frames \*/, "user_id", 42\)$`)
+	if out := fmt.Sprintf("%#v", err); !reg.MatchString(out) {
+		t.Fatalf("expected a readable representation, got %v", out)
+	}
+
+	expected := `errors.Wrapf(&errors.errorString{s:"global_defined_error"}, "test_wrapper") /* 0 frames */`
+	if out := fmt.Sprintf("%#v", &base{info: wrapper, err: ErrTest}); out != expected {
+		t.Fatalf("expected %v, got %v", expected, out)
+	}
+
+	if out := fmt.Sprintf("%#v", Wrapf(&selfWrapping{}, wrapper)); !strings.HasPrefix(out, "errors.Wrapf(&errors.selfWrapping{}") {
+		t.Fatalf("expected a self wrapping error to be represented, got %v", out)
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	other := Raw("other")
+	cases := []struct {
+		format string
+		args   []any
+		causes []error
+	}{
+		{format: "no verb"},
+		{format: "value %d", args: []any{42}},
+		{format: "wrap %w", args: []any{ErrTest}, causes: []error{ErrTest}},
+		{format: "wrap %w and %w", args: []any{ErrTest, other}, causes: []error{ErrTest, other}},
+	}
+	for i, c := range cases {
+		t.Run("TestCase"+strconv.Itoa(i), func(t *testing.T) {
+			err := Errorf(c.format, c.args...)
+			if expected := fmt.Errorf(c.format, c.args...).Error(); err.Error() != expected {
+				t.Fatalf("expected %v, got %v", expected, err.Error())
+			}
+			for _, cause := range c.causes {
+				if !Is(err, cause) {
+					t.Fatalf("expected %v to be in the chain", cause)
+				}
+			}
+			if len(c.causes) == 0 && Unwrap(err) != nil {
+				t.Fatalf("expected no cause, got %v", Unwrap(err))
+			}
+			reg := regexp.MustCompile(`^[^\n]+\n> github\.com\/mawngo\/go-errors\.TestErrorf\.func1	.*\/go-errors\/errors_test\.go:\d+`)
+			if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+				t.Fatalf("expected the first frame to be the caller, got:\n%+v", err)
+			}
+		})
+	}
+
+	var e *base
+	if !As(Errorf("wrap %w", Newf(msg)), &e) || e.info != "wrap test_error_message" {
+		t.Fatalf("expected the outermost error to be a base")
+	}
+}
+
+// BenchmarkNewf measures the create-but-don't-format path, such as sentinels compared with Is,
+// which only captures program counters.
+func BenchmarkNewf(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		err := Newf(msg)
+		if Is(err, ErrTest) {
+			b.Fatal("unexpected match")
+		}
+	}
+}
+
+// BenchmarkNewfError measures the creation of an error followed by a call to Error,
+// which must not resolve the stacktrace.
+func BenchmarkNewfError(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = Newf(msg).Error()
+	}
+}
+
+// BenchmarkNewfFormat measures the creation of an error followed by its formatting with "%+v",
+// which resolves the stacktrace into frames.
+func BenchmarkNewfFormat(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = fmt.Sprintf("%+v", Newf(msg))
+	}
+}
+
+// atDepth calls fn below depth additional frames.
+func atDepth(depth int, fn func()) {
+	if depth == 0 {
+		fn()
+		return
+	}
+	atDepth(depth-1, fn)
+}
+
+// BenchmarkNewfDeepStack measures the creation of errors deep in the stack, where the capture
+// must not walk the frames beyond MaxStackDepth.
+func BenchmarkNewfDeepStack(b *testing.B) {
+	for _, depth := range []int{10, 500, 5000} {
+		b.Run(strconv.Itoa(depth), func(b *testing.B) {
+			atDepth(depth, func() {
+				b.ReportAllocs()
+				for b.Loop() {
+					_ = Newf(msg)
+				}
+			})
+		})
+	}
+}
+
+func TestWrapfNoStack(t *testing.T) {
+	err := WrapfNoStack(Newf(msg), "%s %d", wrapper, 1)
+	if err.Error() != "test_wrapper 1: test_error_message" {
+		t.Fatalf("expected the message to be added, got %v", err)
+	}
+	reg := regexp.MustCompile(`^test_wrapper 1\ntest_error_message\n> github\.com\/mawngo\/go-errors\.TestWrapfNoStack	`)
+	if out := fmt.Sprintf("%+v", err); !reg.MatchString(out) {
+		t.Fatalf("expected only the message line for the error without stacktrace, got:\n%v", out)
+	}
+	if WrapfNoStack(nil, wrapper) != nil {
+		t.Fatalf("expected nil for nil cause")
+	}
+}
+
+func TestOneLineStack(t *testing.T) {
+	err := WrapfNoStack(Wrapf(ErrTest, wrapper), wrapper)
+	_, _, line, _ := runtime.Caller(0)
+
+	reg := regexp.MustCompile(`^errors_test\.go:` + strconv.Itoa(line-1) + ` <- testing\.go:\d+ <- asm_[^ ]+\.s:\d+$`)
+	if out := OneLineStack(err); !reg.MatchString(out) {
+		t.Fatalf("expected a one line summary of the stacktrace, got %q", out)
+	}
+
+	OneLineStackFrames = 1
+	defer func() {
+		OneLineStackFrames = 3
+	}()
+	expected := "errors_test.go:" + strconv.Itoa(line-1)
+	if out := OneLineStack(err); out != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+	if out := OneLineStack(ErrTest); out != "" {
+		t.Fatalf("expected empty summary for an error without stacktrace, got %q", out)
+	}
+}
+
+var errSentinel = NewSentinel("sentinel")
+
+func TestNewSentinel(t *testing.T) {
+	if out := fmt.Sprintf("%+v", errSentinel); out != "sentinel\n" {
+		t.Fatalf("expected no stacktrace for the sentinel, got:\n%v", out)
+	}
+	if Is(NewSentinel("sentinel"), errSentinel) {
+		t.Fatalf("expected distinct sentinels not to match")
+	}
+
+	for i, err := range []error{
+		Wrap(errSentinel),
+		WithStack(errSentinel),
+		Wrapf(errSentinel, wrapper),
+	} {
+		t.Run("TestCase"+strconv.Itoa(i), func(t *testing.T) {
+			if !Is(err, errSentinel) {
+				t.Fatalf("expected the sentinel to match")
+			}
+			reg := regexp.MustCompile(`^[^\n]+\n> github\.com\/mawngo\/go-errors\.TestNewSentinel	.*\/go-errors\/errors_test\.go:\d+`)
+			if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+				t.Fatalf("expected the stacktrace of the wrap site, got:\n%+v", err)
+			}
+		})
+	}
+	if err := Wrap(errSentinel); err.Error() != "sentinel" {
+		t.Fatalf("expected the message to be preserved, got %v", err)
+	}
+}
+
+var errStored = Newf("stored")
+
+func TestIsSentinel(t *testing.T) {
+	err := Reanchor(errStored)
+	if !Is(Wrapf(err, wrapper), errStored) {
+		t.Fatalf("expected the copy to match the original error")
+	}
+	if !Is(Reanchor(err), errStored) {
+		t.Fatalf("expected the copy of a copy to match the original error")
+	}
+	if !Is(Reanchor(errSentinel), errSentinel) {
+		t.Fatalf("expected the copy to match the sentinel")
+	}
+	if Is(errStored, err) {
+		t.Fatalf("expected the original error not to match its copy")
+	}
+	if Is(Newf("stored"), errStored) || Is(Reanchor(Newf("stored")), errStored) {
+		t.Fatalf("expected distinct errors with the same message not to match")
+	}
+}
+
+func TestFrameFormat(t *testing.T) {
+	FrameFormat = func(frame Frame) string {
+		return "\t" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line) + " " + frame.Function
+	}
+	defer func() {
+		FrameFormat = formatFrame
+	}()
+
+	reg := regexp.MustCompile(`^test_error_message\n\terrors_test\.go:\d+ github\.com\/mawngo\/go-errors\.TestFrameFormat\n`)
+	if out := fmt.Sprintf("%+v", Newf(msg)); !reg.MatchString(out) {
+		t.Fatalf("expected the frames to use the custom format, got:\n%v", out)
+	}
+
+	FrameFormat = nil
+	reg = regexp.MustCompile(`^test_error_message\n> github\.com\/mawngo\/go-errors\.TestFrameFormat	`)
+	if out := fmt.Sprintf("%+v", Newf(msg)); !reg.MatchString(out) {
+		t.Fatalf("expected the default format, got:\n%v", out)
+	}
+}
+
+func TestShortFilePaths(t *testing.T) {
+	ShortFilePaths = true
+	defer func() {
+		ShortFilePaths = false
+	}()
+
+	reg := regexp.MustCompile(`^test_error_message\n> github\.com\/mawngo\/go-errors\.TestShortFilePaths\t[^/]+/errors_test\.go:\d+\n`)
+	if out := fmt.Sprintf("%+v", Newf(msg)); !reg.MatchString(out) {
+		t.Fatalf("expected the file paths to be trimmed, got:\n%v", out)
+	}
+	if file := shortFilePath("errors.go"); file != "errors.go" {
+		t.Fatalf("expected the file name to be unchanged, got %q", file)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	a, b := Raw("a"), Raw("b")
+	err := Wrapf(Join(WithCode(a, "code"), nil, Wrapf(b, wrapper)), wrapper)
+
+	var visited []string
+	Walk(err, func(err error) bool {
+		visited = append(visited, err.Error())
+		return true
+	})
+	expected := []string{
+		"test_wrapper: a\ntest_wrapper: b",
+		"a\ntest_wrapper: b",
+		"a",
+		"a",
+		"test_wrapper: b",
+		"b",
+	}
+	if !slices.Equal(visited, expected) {
+		t.Fatalf("expected %q, got %q", expected, visited)
+	}
+
+	var count int
+	Walk(err, func(err error) bool {
+		count++
+		return err != a //nolint:errorlint
+	})
+	if count != 4 {
+		t.Fatalf("expected the traversal to stop at the first leaf, got %d visits", count)
+	}
+	Walk(nil, func(error) bool {
+		t.Fatalf("expected no visit for nil error")
+		return true
+	})
+}
+
+func TestLeaves(t *testing.T) {
+	a, b, c := Raw("a"), Newf("b"), Raw("c")
+	err := Wrapf(Join(WithCode(a, "code"), nil, Wrapf(Join(b, c), wrapper)), wrapper)
+	if leaves := Leaves(err); !slices.Equal(leaves, []error{a, b, c}) {
+		t.Fatalf("expected the leaves in traversal order, got %v", leaves)
+	}
+	if leaves := Leaves(Wrapf(a, wrapper)); !slices.Equal(leaves, []error{a}) {
+		t.Fatalf("expected a single leaf, got %v", leaves)
+	}
+	if leaves := Leaves(nil); leaves != nil {
+		t.Fatalf("expected nil, got %v", leaves)
+	}
+}
+
+func TestWrapw(t *testing.T) {
+	cause := WithCode(Newf(msg), "code")
+	err := Wrapw(cause, errSentinel, "%s %d", wrapper, 1)
+	if err.Error() != "test_wrapper 1: test_error_message" {
+		t.Fatalf("expected the message of the cause, got %v", err)
+	}
+	if !Is(err, errSentinel) || !Is(err, cause) {
+		t.Fatalf("expected both the sentinel and the cause to match")
+	}
+	if leaves := Leaves(err); len(leaves) != 2 || leaves[1] != errSentinel { //nolint:errorlint
+		t.Fatalf("expected the sentinel to be traversable, got %v", leaves)
+	}
+
+	reg := regexp.MustCompile(`^test_wrapper 1
+> github\.com\/mawngo\/go-errors\.TestWrapw	[^\n]+
+[[:ascii:]]+code=code
+test_error_message
+> github\.com\/mawngo\/go-errors\.TestWrapw	`)
+	if out := fmt.Sprintf("%+v", err); !reg.MatchString(out) || strings.Contains(out, "sentinel") {
+		t.Fatalf("expected the chain of the cause to be formatted, got:\n%v", out)
+	}
+
+	if Wrapw(nil, errSentinel, wrapper) != nil {
+		t.Fatalf("expected nil for nil cause")
+	}
+	if err := Wrapw(ErrTest, nil, wrapper); Unwrap(err) != ErrTest { //nolint:errorlint
+		t.Fatalf("expected a nil sentinel to be ignored, got %v", Unwrap(err))
+	}
+	expected := `errors.Wrapw(&errors.errorString{s:"global_defined_error"}, errors.Newf("sentinel") /* 0 frames */, "test_wrapper")`
+	if out := fmt.Sprintf("%#v", Wrapw(ErrTest, errSentinel, wrapper)); !strings.HasPrefix(out, expected) {
+		t.Fatalf("expected %v, got %v", expected, out)
+	}
+	reg = regexp.MustCompile(`^test_wrapper\n> github\.com\/mawngo\/go-errors\.TestWrapw	`)
+	if out := fmt.Sprintf("%+v", Wrapw(ErrTest, errSentinel, wrapper)); !reg.MatchString(out) {
+		t.Fatalf("expected the stacktrace to point at the caller, got:\n%v", out)
+	}
+}
+
+func TestCaptureStack(t *testing.T) {
+	CaptureStack = false
+	defer func() {
+		CaptureStack = true
+	}()
+
+	err := Wrapf(Newf(msg), wrapper)
+	if out := fmt.Sprintf("%+v", err); out != "test_wrapper\ntest_error_message\n" {
+		t.Fatalf("expected only the messages, got:\n%v", out)
+	}
+	if out := fmt.Sprintf("%+v", Guard(func() error { panic("boom") })); out != "panic: boom\n" {
+		t.Fatalf("expected only the message of the panic, got:\n%v", out)
+	}
+
+	CaptureStack = true
+	if _, ok := StackTrace(Newf(msg)); !ok {
+		t.Fatalf("expected the stacktrace to be captured again")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(true, "%s", msg); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	err := Check(false, "x must be positive, got %d", -1)
+	reg := regexp.MustCompile(`^x must be positive, got -1\n> github\.com\/mawngo\/go-errors\.TestCheck	.*\/go-errors\/errors_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the stacktrace to point at the caller, got:\n%+v", err)
+	}
+}
+
+func TestStackPackagePrefix(t *testing.T) {
+	StackPackagePrefix = "github.com/mawngo/"
+	defer func() {
+		StackPackagePrefix = ""
+	}()
+
+	reg := regexp.MustCompile(`^test_error_message\n> github\.com\/mawngo\/go-errors\.TestStackPackagePrefix	[^\n]+\n$`)
+	if out := fmt.Sprintf("%+v", Newf(msg)); !reg.MatchString(out) {
+		t.Fatalf("expected only the frames of the package, got:\n%v", out)
+	}
+
+	StackPackagePrefix = "example.com/"
+	reg = regexp.MustCompile(`^test_error_message\n> github\.com\/mawngo\/go-errors\.TestStackPackagePrefix	[^\n]+\n> testing\.tRunner	`)
+	if out := fmt.Sprintf("%+v", Newf(msg)); !reg.MatchString(out) {
+		t.Fatalf("expected all frames when none matches, got:\n%v", out)
+	}
+}
+
+func TestStackSkipFunc(t *testing.T) {
+	StackSkipFunc = func(frame Frame) bool {
+		return strings.HasPrefix(frame.Function, "testing.")
+	}
+	defer func() {
+		StackSkipFunc = nil
+	}()
+
+	out := fmt.Sprintf("%+v", Newf(msg))
+	if !strings.Contains(out, "TestStackSkipFunc") || strings.Contains(out, "testing.tRunner") {
+		t.Fatalf("expected the matching frames to be omitted, got:\n%v", out)
+	}
+
+	StackSkipFunc = func(Frame) bool { return true }
+	if out := fmt.Sprintf("%+v", Newf(msg)); !strings.Contains(out, "TestStackSkipFunc") {
+		t.Fatalf("expected all frames when every frame is omitted, got:\n%v", out)
+	}
+}
+
+func TestFramesFormat(t *testing.T) {
+	frames, _ := StackTrace(Wrapf(Newf(msg), wrapper))
+	_, _, line, _ := runtime.Caller(0)
+
+	reg := regexp.MustCompile(`^> github\.com\/mawngo\/go-errors\.TestFramesFormat	.*\/go-errors\/errors_test\.go:` + strconv.Itoa(line-1) + `\n> testing\.tRunner	[^\n]+\n`)
+	if out := fmt.Sprintf("%+v", frames); !reg.MatchString(out) || strings.Count(out, "\n") != len(frames) {
+		t.Fatalf("expected one line per frame, got:\n%v", out)
+	}
+	reg = regexp.MustCompile(`^errors_test\.go:` + strconv.Itoa(line-1) + ` <- testing\.go:\d+ <- `)
+	if out := fmt.Sprintf("%v", frames); !reg.MatchString(out) || strings.Count(out, " <- ") != len(frames)-1 {
+		t.Fatalf("expected a single line summary, got %v", out)
+	}
+}
+
+func TestJoinStack(t *testing.T) {
+	a, b := Newf("a"), Raw("b")
+	err := JoinStack(a, nil, b)
+	if err.Error() != Join(a, b).Error() {
+		t.Fatalf("expected the message of Join, got %q", err.Error())
+	}
+	if !Is(err, a) || !Is(err, b) {
+		t.Fatalf("expected the joined errors to match")
+	}
+	if leaves := Leaves(err); !slices.Equal(leaves, []error{a, b}) {
+		t.Fatalf("expected the joined errors to be traversable, got %v", leaves)
+	}
+
+	reg := regexp.MustCompile(`^joined errors
+> github\.com\/mawngo\/go-errors\.TestJoinStack	[^\n]+
+[[:ascii:]]*    a
+    > github\.com\/mawngo\/go-errors\.TestJoinStack	[^\n]+
+[[:ascii:]]*    b
+$`)
+	if out := fmt.Sprintf("%+v", err); !reg.MatchString(out) {
+		t.Fatalf("expected the call site followed by each joined error, got:\n%v", out)
+	}
+
+	reg = regexp.MustCompile(`^wrap a and b
+> github\.com\/mawngo\/go-errors\.TestJoinStack	[^\n]+
+[[:ascii:]]*    a
+    > github\.com\/mawngo\/go-errors\.TestJoinStack	[^\n]+
+[[:ascii:]]*    b
+$`)
+	if out := fmt.Sprintf("%+v", Errorf("wrap %w and %w", a, b)); !reg.MatchString(out) {
+		t.Fatalf("expected the message of the multiple wrap to be kept, got:\n%v", out)
+	}
+
+	if JoinStack(nil, nil) != nil {
+		t.Fatalf("expected nil when every error is nil")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a := Wrapf(Newf(msg), wrapper)
+	b := Wrapf(Newf(msg), wrapper)
+	if !Equal(a, b) || !EqualChain(a, b) {
+		t.Fatalf("expected errors created at different call sites to be equal")
+	}
+
+	if !Equal(Wrapf(Newf(wrapper+": "+msg), wrapper), Wrapf(Newf(msg), wrapper+": "+wrapper)) || Equal(a, Wrapf(Newf(msg), "other")) {
+		t.Fatalf("unexpected Equal result")
+	}
+	if EqualChain(Wrapf(Newf(wrapper+": "+msg), wrapper), Wrapf(Newf(msg), wrapper+": "+wrapper)) {
+		t.Fatalf("expected chains of different shapes not to be equal")
+	}
+	if EqualChain(WithCode(a, "a"), WithCode(b, "b")) || !EqualChain(WithCode(a, "a"), WithCode(b, "a")) {
+		t.Fatalf("expected the metadata to be compared")
+	}
+
+	if !Equal(nil, nil) || Equal(a, nil) || Equal(nil, a) || !EqualChain(nil, nil) || EqualChain(a, nil) {
+		t.Fatalf("unexpected result for nil errors")
+	}
+}
+
+func TestWithMessage(t *testing.T) {
+	original := Wrapf(ErrTest, wrapper)
+	err := WithMessage(original, "%s %d", "sanitized", 1)
+	if err.Error() != "sanitized 1: "+ErrTest.Error() || !Is(err, ErrTest) {
+		t.Fatalf("expected the message to be replaced and the cause kept, got %v", err)
+	}
+	if original.Error() != wrapper+": "+ErrTest.Error() {
+		t.Fatalf("expected the original error not to be modified, got %v", original)
+	}
+	if !slices.Equal(err.(*base).StackTrace(), original.(*base).StackTrace()) { //nolint:errorlint
+		t.Fatalf("expected the stacktrace to be kept")
+	}
+
+	reg := regexp.MustCompile(`^sanitized[ \n]+> github\.com\/mawngo\/go-errors\.TestWithMessage	`)
+	if !reg.MatchString(fmt.Sprintf("%+v", WithMessage(ErrTest, "sanitized"))) {
+		t.Fatalf("expected a foreign error to be wrapped at the call site")
+	}
+	if WithMessage(nil, "sanitized") != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+	SetStrictNilWrap(true)
+	defer SetStrictNilWrap(false)
+	if WithMessage(nil, "sanitized") != nil {
+		t.Fatalf("expected nil for nil error in strict mode")
+	}
+}
+
+func TestFormatJoinedTree(t *testing.T) {
+	TrimFramePrefixes = []string{"testing.", "runtime."}
+	defer func() {
+		TrimFramePrefixes = nil
+	}()
+
+	err := Wrapf(Join(Raw("a"), Wrapf(Join(Raw("b"), Raw("c")), "inner")), "outer")
+	reg := regexp.MustCompile(`^outer
+> github\.com\/mawngo\/go-errors\.TestFormatJoinedTree	[^\n]+
+    a
+    inner
+    > github\.com\/mawngo\/go-errors\.TestFormatJoinedTree	[^\n]+
+        b
+        c
+$`)
+	if out := fmt.Sprintf("%+v", err); !reg.MatchString(out) {
+		t.Fatalf("expected the joined errors to be indented per level, got:\n%v", out)
+	}
+}
+
+func hereHelper() string {
+	return Here(1)
+}
+
+func TestHere(t *testing.T) {
+	here := Here(0)
+	_, _, line, _ := runtime.Caller(0)
+	if expected := "errors_test.go:" + strconv.Itoa(line-1); here != expected {
+		t.Fatalf("expected %q, got %q", expected, here)
+	}
+	helper := hereHelper()
+	if expected := "errors_test.go:" + strconv.Itoa(line+4); helper != expected {
+		t.Fatalf("expected the caller of the helper %q, got %q", expected, helper)
+	}
+	if out := Here(1000); out != "" {
+		t.Fatalf("expected an empty location, got %q", out)
+	}
+}
+
+func TestMessageJoiner(t *testing.T) {
+	MessageJoiner = func(info, cause string) string {
+		return cause + " (" + info + ")"
+	}
+	defer func() {
+		MessageJoiner = joinMessage
+	}()
+
+	err := Wrapf(Wrapf(ErrTest, "inner"), "outer")
+	if expected := "global_defined_error (inner) (outer)"; err.Error() != expected {
+		t.Fatalf("expected %q, got %q", expected, err.Error())
+	}
+
+	MessageJoiner = nil
+	if expected := "outer: inner: global_defined_error"; err.Error() != expected {
+		t.Fatalf("expected the default composition %q, got %q", expected, err.Error())
+	}
+}
+
+func TestStackContains(t *testing.T) {
+	err := WrapfNoStack(wrapHelper(ErrTest), wrapper)
+	if !StackContains(err, "go-errors.TestStackContains") || !StackContains(err, "testing.tRunner") {
+		t.Fatalf("expected the frames to be found")
+	}
+	if StackContains(err, "go-errors.wrapHelper") {
+		t.Fatalf("expected the skipped frames not to be found")
+	}
+	if StackContains(ErrTest, "") || StackContains(nil, "") {
+		t.Fatalf("expected false for an error without stacktrace")
+	}
+}
+
+func TestChain(t *testing.T) {
+	err := Wrapf(WithCode(Wrap(Wrapf(ErrTest, "inner")), "code"), "outer")
+	if chain := Chain(err); !slices.Equal(chain, []string{"outer", "inner", ErrTest.Error()}) {
+		t.Fatalf("expected the messages of each layer, got %q", chain)
+	}
+	if chain := Chain(Wrapf(Join(Raw("a"), Newf("b")), wrapper)); !slices.Equal(chain, []string{wrapper, "a\nb"}) {
+		t.Fatalf("expected the joined errors to be the last layer, got %q", chain)
+	}
+	if chain := Chain(&selfWrapping{}); len(chain) != MaxChainDepth {
+		t.Fatalf("expected the traversal to be bounded, got %d layers", len(chain))
+	}
+	if Chain(nil) != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+// selfReferential is a foreign error that unwraps to itself.
+type selfReferential struct{}
+
+func (e *selfReferential) Error() string { return "self" }
+func (e *selfReferential) Unwrap() error { return e }
+
+func TestSelfReferentialChain(t *testing.T) {
+	err := Wrapf(&selfReferential{}, wrapper)
+	if out := fmt.Sprintf("%+v", err); !strings.HasPrefix(out, wrapper+"\n") || !strings.HasSuffix(out, "self\n") {
+		t.Fatalf("expected the foreign error to end the chain, got:\n%v", out)
+	}
+	if out := FormatJSONL(err); !strings.HasSuffix(out, `{"message":"self"}`+"\n") {
+		t.Fatalf("expected the foreign error to end the chain, got:\n%v", out)
+	}
+	if out := FormatWithinBudget(err, 100); !strings.HasSuffix(out, "self\n") {
+		t.Fatalf("expected the foreign error to end the chain, got:\n%v", out)
+	}
+	if _, e := MarshalJSON(err); e != nil {
+		t.Fatalf("expected no error, got %v", e)
+	}
+	if annotations := Annotations(err); !slices.Equal(annotations, []string{wrapper}) {
+		t.Fatalf("expected the annotation of the wrapper, got %q", annotations)
+	}
+	if value := logValue(&selfReferential{}); len(value.Group()) != 1 {
+		t.Fatalf("expected only the message, got %v", value)
+	}
+}
+
+func TestMaxChainDepth(t *testing.T) {
+	MaxChainDepth = 3
+	defer func() {
+		MaxChainDepth = 100
+	}()
+	err := Newf("root")
+	for range 5 {
+		err = Wrapf(err, wrapper)
+	}
+	if out := fmt.Sprintf("%+v", err); !strings.HasSuffix(out, "... (chain truncated)\n") || strings.Contains(out, "root") {
+		t.Fatalf("expected the chain to be truncated, got %q", out)
+	}
+	if Cause(&selfWrapping{}) == nil {
+		t.Fatalf("expected the traversal of a self-referential chain to stop")
+	}
+	if annotations := Annotations(err); len(annotations) != MaxChainDepth {
+		t.Fatalf("expected the annotations to be bounded, got %q", annotations)
+	}
+}
+
+func TestCaptureGoroutineID(t *testing.T) {
+	if out := fmt.Sprintf("%+v", Newf(msg)); strings.Contains(out, "goroutine ") {
+		t.Fatalf("expected no goroutine header by default, got:\n%v", out)
+	}
+
+	CaptureGoroutineID = true
+	defer func() {
+		CaptureGoroutineID = false
+	}()
+	errs := make(chan error)
+	go func() {
+		errs <- Newf(msg)
+	}()
+	err := Wrapf(<-errs, wrapper)
+
+	reg := regexp.MustCompile(`^test_wrapper\ngoroutine (\d+):\n> github\.com\/mawngo\/go-errors\.TestCaptureGoroutineID	[^\n]+\n[[:ascii:]]*test_error_message\ngoroutine (\d+):\n> github\.com\/mawngo\/go-errors\.TestCaptureGoroutineID\.func2	`)
+	match := reg.FindStringSubmatch(fmt.Sprintf("%+v", err))
+	if match == nil || match[1] == match[2] {
+		t.Fatalf("expected the goroutine of each error in the headers, got:\n%+v", err)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	inner := Newf(msg)
+	err := Wrapf(WithCode(Wrapf(inner, wrapper), "code"), "outer")
+	flat := Flatten(err)
+	if flat.Error() != err.Error() {
+		t.Fatalf("expected the message of the chain, got %q", flat.Error())
+	}
+	if Unwrap(flat) != nil || Is(flat, inner) {
+		t.Fatalf("expected no cause")
+	}
+	if !slices.Equal(flat.(*base).StackTrace(), err.(*base).StackTrace()) { //nolint:errorlint
+		t.Fatalf("expected the outermost stacktrace")
+	}
+	if flat := Flatten(WrapfNoStack(inner, wrapper)); !slices.Equal(flat.(*base).StackTrace(), inner.(*base).StackTrace()) { //nolint:errorlint
+		t.Fatalf("expected the nearest stacktrace")
+	}
+	if flat := Flatten(ErrTest); flat.Error() != ErrTest.Error() {
+		t.Fatalf("expected the message of the error, got %q", flat.Error())
+	}
+	if Flatten(nil) != nil {
+		t.Fatalf("expected nil")
+	}
+}
+
+func TestCaptureTime(t *testing.T) {
+	if _, ok := CreatedAt(Newf(msg)); ok {
+		t.Fatalf("expected no creation time by default")
+	}
+
+	CaptureTime = true
+	defer func() {
+		CaptureTime = false
+	}()
+	inner := Newf(msg)
+	created, ok := CreatedAt(inner)
+	if !ok {
+		t.Fatalf("expected the creation time to be recorded")
+	}
+	outer := Wrapf(WithCode(inner, "code"), wrapper)
+	if at, ok := CreatedAt(outer); !ok || !at.Equal(created) {
+		t.Fatalf("expected the creation time of the innermost error, got %v, %v", at, ok)
+	}
+	if at, _ := CreatedAt(Unwrap(outer)); at.Before(created) {
+		t.Fatalf("expected the wrapping to be recorded after the creation, got %v", at)
+	}
+	if _, ok := CreatedAt(ErrTest); ok {
+		t.Fatalf("expected no creation time")
+	}
+}
+
+func TestAsType(t *testing.T) {
+	_, cause := os.Open("does-not-exist")
+	err := Wrapf(WithCode(cause, "code"), wrapper)
+
+	pathErr, ok := AsType[*os.PathError](err)
+	if !ok || pathErr.Path != "does-not-exist" {
+		t.Fatalf("expected the path error, got %v, %v", pathErr, ok)
+	}
+	if timeout, ok := AsType[interface {
+		error
+		Timeout() bool
+	}](err); !ok || timeout.Timeout() {
+		t.Fatalf("expected an interface target to match, got %v, %v", timeout, ok)
+	}
+	if linkErr, ok := AsType[*os.LinkError](err); ok || linkErr != nil {
+		t.Fatalf("expected the zero value, got %v, %v", linkErr, ok)
+	}
+	if _, ok := AsType[*os.PathError](nil); ok {
+		t.Fatalf("expected false for nil")
+	}
+}
+
+func TestAllAs(t *testing.T) {
+	_, first := os.Open("first")
+	_, second := os.Open("second")
+	err := Wrapf(Join(WithCode(first, "code"), Wrapf(Join(ErrTest, second), wrapper)), wrapper)
+
+	pathErrs := AllAs[*os.PathError](err)
+	if len(pathErrs) != 2 || pathErrs[0].Path != "first" || pathErrs[1].Path != "second" {
+		t.Fatalf("expected every path error in traversal order, got %v", pathErrs)
+	}
+	if joined := AllAs[interface {
+		error
+		Unwrap() []error
+	}](err); len(joined) != 2 {
+		t.Fatalf("expected every joined error, got %d", len(joined))
+	}
+	if AllAs[*os.PathError](ErrTest) != nil || AllAs[*os.PathError](nil) != nil {
+		t.Fatalf("expected no match")
+	}
+}
+
+func TestNewWrapf(t *testing.T) {
+	err := NewWrapf(nil, msg, wrapper)
+	if err.Error() != wrapper+": "+msg {
+		t.Fatalf("expected both messages, got %v", err)
+	}
+	reg := regexp.MustCompile(`^test_wrapper\ntest_error_message\n> github\.com\/mawngo\/go-errors\.TestNewWrapf	[^\n]+\n`)
+	if out := fmt.Sprintf("%+v", err); !reg.MatchString(out) || strings.Count(out, "TestNewWrapf") != 1 {
+		t.Fatalf("expected a single stacktrace, got:\n%v", out)
+	}
+
+	err = NewWrapf(ErrTest, msg, wrapper)
+	if err.Error() != wrapper+": "+msg+": "+ErrTest.Error() || !Is(err, ErrTest) {
+		t.Fatalf("expected the cause to be wrapped, got %v", err)
+	}
+}
+
+func TestFormatPrecision(t *testing.T) {
+	err := WithCode(Wrapf(deepStack(5), wrapper), "code")
+	reg := regexp.MustCompile(`^code=code
+test_wrapper
+> github\.com\/mawngo\/go-errors\.TestFormatPrecision	[^\n]+
+> testing\.tRunner	[^\n]+
+\.\.\. \(\d+ more frames\)
+test_error_message
+> github\.com\/mawngo\/go-errors\.deepStack	[^\n]+
+> github\.com\/mawngo\/go-errors\.deepStack	[^\n]+
+\.\.\. \(\d+ more frames\)
+$`)
+	if out := fmt.Sprintf("%+.2v", err); !reg.MatchString(out) {
+		t.Fatalf("expected at most two frames per error, got:\n%v", out)
+	}
+	if out := fmt.Sprintf("%+.0v", err); strings.Contains(out, "> ") {
+		t.Fatalf("expected no frames, got:\n%v", out)
+	}
+	if fmt.Sprintf("%+v", err) != formatErrorChain(err) {
+		t.Fatalf("expected all frames without precision")
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	Redactor = func(msg string) string {
+		return strings.ReplaceAll(msg, "secret", "***")
+	}
+	defer func() {
+		Redactor = nil
+	}()
+
+	err := Wrapf(Newf("token secret rejected"), "login with secret")
+	outputs := map[string]string{
+		"Error": err.Error(),
+		"%v":    fmt.Sprintf("%v", err),
+		"%+v":   fmt.Sprintf("%+v", err),
+		"%#v":   fmt.Sprintf("%#v", err),
+		"tree":  FormatTree(err),
+		"jsonl": FormatJSONL(err),
+	}
+	out, _ := MarshalJSON(err)
+	outputs["json"] = string(out)
+	var buf strings.Builder
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", "err", err)
+	outputs["slog"] = buf.String()
+
+	for name, out := range outputs {
+		if strings.Contains(out, "secret") || !strings.Contains(out, "token *** rejected") {
+			t.Fatalf("expected the %s output to be redacted, got:\n%v", name, out)
+		}
+	}
+	if err.Error() != "login with ***: token *** rejected" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestWrapif(t *testing.T) {
+	notTest := func(err error) bool {
+		return !Is(err, ErrTest)
+	}
+	if err := Wrapif(ErrTest, notTest, wrapper); err != ErrTest { //nolint:errorlint
+		t.Fatalf("expected the cause to be returned unchanged, got %v", err)
+	}
+
+	cause := Raw("other")
+	err := Wrapif(cause, notTest, "%s %d", wrapper, 1)
+	reg := regexp.MustCompile(`^test_wrapper 1\n> github\.com\/mawngo\/go-errors\.TestWrapif	.*\/go-errors\/errors_test\.go:\d+`)
+	if !Is(err, cause) || !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the cause to be wrapped at the call site, got:\n%+v", err)
+	}
+
+	if Wrapif(nil, func(error) bool { t.Fatalf("expected pred not to be called"); return true }, wrapper) != nil {
+		t.Fatalf("expected nil for nil cause")
+	}
+}
+
+func TestGetFrames(t *testing.T) {
+	err := WrapfNoStack(Newf(msg), wrapper)
+	_, _, line, _ := runtime.Caller(0)
+
+	frames := GetFrames(err)
+	if len(frames) == 0 || frames[0].Function != "github.com/mawngo/go-errors.TestGetFrames" || frames[0].Line != line-1 {
+		t.Fatalf("expected the frames of the nearest stacktrace, got %+v", frames)
+	}
+	var st interface{ Frames() []Frame }
+	if !As(Unwrap(err), &st) || !slices.Equal(st.Frames(), frames) {
+		t.Fatalf("expected the error to expose its frames")
+	}
+	if GetFrames(ErrTest) != nil || GetFrames(nil) != nil {
+		t.Fatalf("expected nil for an error without stacktrace")
+	}
+}
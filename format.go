@@ -0,0 +1,262 @@
+package errors
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonlMessage is the JSON Lines representation of an error message.
+type jsonlMessage struct {
+	Message string `json:"message"`
+}
+
+// jsonlFrame is the JSON Lines representation of a stack frame.
+type jsonlFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// FormatJSONL formats an error chain as JSON Lines, for log collectors that parse one JSON object per line.
+// Each error of the chain is emitted as a {"message": ...} object,
+// followed by one {"function": ..., "file": ..., "line": ...} object per frame of its stacktrace.
+// The chains of joined errors, such as returned by [Join], are emitted one after the other.
+func FormatJSONL(err error) string {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	writeJSONL(enc, err, 0)
+	return buf.String()
+}
+
+// writeJSONL writes the JSON Lines of an error chain to enc.
+// depth is the number of errors of the chain already written.
+func writeJSONL(enc *json.Encoder, err error, depth int) {
+	for ; err != nil && depth < MaxChainDepth; depth++ {
+		if errs, ok := joinedErrors(err); ok {
+			for _, e := range errs {
+				writeJSONL(enc, e, depth+1)
+			}
+			return
+		}
+		e, steps, ok := findBase(err, MaxChainDepth-depth)
+		if !ok {
+			_ = enc.Encode(jsonlMessage{Message: err.Error()})
+			break
+		}
+		depth += steps
+		_ = enc.Encode(jsonlMessage{Message: e.message()})
+		for _, frame := range e.stack.frames() {
+			_ = enc.Encode(jsonlFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		err = e.err
+	}
+}
+
+// FormatWithinBudget formats an error chain like the "%+v" verb does, but renders at most maxLines lines.
+// When lines must be dropped, error messages are kept over stack frames, and the origin frame
+// (where the deepest error carrying a stacktrace was created) is kept over the other frames.
+// The remaining budget is spent on frames from the outermost error toward the root.
+// The chains of joined errors, such as returned by [Join], are rendered indented, each with its messages.
+func FormatWithinBudget(err error, maxLines int) string {
+	type line struct {
+		text     string
+		priority int
+	}
+	const (
+		messagePriority = iota
+		originPriority
+		framePriority
+	)
+
+	var lines []line
+	origin := -1
+	// the chains of joined errors are collected with an indent, like the "%+v" verb does.
+	var collect func(err error, indent string, depth int)
+	collect = func(err error, indent string, depth int) {
+		for ; err != nil && depth < MaxChainDepth; depth++ {
+			if errs, ok := joinedErrors(err); ok {
+				for _, e := range errs {
+					collect(e, indent+joinedIndent, depth+1)
+				}
+				return
+			}
+			e, steps, ok := findBase(err, MaxChainDepth-depth)
+			if !ok {
+				lines = append(lines, line{text: indent + err.Error() + "\n", priority: messagePriority})
+				return
+			}
+			depth += steps
+			lines = append(lines, line{text: indent + e.message() + "\n", priority: messagePriority})
+			for i, frame := range e.stack.frames() {
+				var buf strings.Builder
+				writeFrame(&buf, frame, FrameFormat)
+				if i == 0 {
+					origin = len(lines)
+				}
+				lines = append(lines, line{text: indent + buf.String(), priority: framePriority})
+			}
+			err = e.err
+		}
+	}
+	collect(err, "", 0)
+	if origin >= 0 {
+		lines[origin].priority = originPriority
+	}
+
+	// Stable sort keeps the chain order within the same priority.
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return lines[order[i]].priority < lines[order[j]].priority
+	})
+	keep := make([]bool, len(lines))
+	for _, i := range order[:min(max(maxLines, 0), len(order))] {
+		keep[i] = true
+	}
+
+	var buf strings.Builder
+	for i, l := range lines {
+		if keep[i] {
+			buf.WriteString(l.text)
+		}
+	}
+	return buf.String()
+}
+
+// FormatTree formats an error chain as an ASCII tree showing the branches of joined errors,
+// with one message per line. A chain without joined errors renders as a simple list of messages,
+// from the outermost error toward the root. Stacktraces are omitted, use the "%+v" verb to print them.
+//
+// For example:
+//
+//	batch failed
+//	├─ record a at index 0
+//	│  connection refused
+//	└─ record b at index 1
+//	   timeout
+func FormatTree(err error) string {
+	var buf strings.Builder
+	writeTree(&buf, err, "", "")
+	return buf.String()
+}
+
+// writeTree writes the tree of err to buf. The first line is prefixed by first, and the following lines by prefix.
+func writeTree(buf *strings.Builder, err error, first, prefix string) {
+	written := false
+	writeLine := func(s string) {
+		if written {
+			buf.WriteString(prefix)
+		} else {
+			buf.WriteString(first)
+		}
+		buf.WriteString(strings.ReplaceAll(s, "\n", "\n"+prefix))
+		buf.WriteString("\n")
+		written = true
+	}
+
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok {
+			writeLine(fmt.Sprintf("%s=%v", v.key, v.value))
+			err = v.err
+			continue
+		}
+		if t, ok := err.(*tagged); ok { //nolint:errorlint
+			// the sentinel is not part of the chain, only the cause is rendered.
+			err = t.err
+			continue
+		}
+		if p, ok := err.(*PanicError); ok { //nolint:errorlint
+			err = p.err
+			continue
+		}
+		if e, ok := asBase(err); ok {
+			writeLine(e.message())
+			err = e.err
+			continue
+		}
+		joined, ok := err.(interface{ Unwrap() []error }) //nolint:errorlint
+		if !ok {
+			writeLine(err.Error())
+			return
+		}
+		if !written {
+			writeLine("joined errors")
+		}
+		errs := make([]error, 0, len(joined.Unwrap()))
+		for _, e := range joined.Unwrap() {
+			if e != nil {
+				errs = append(errs, e)
+			}
+		}
+		for i, e := range errs {
+			if i == len(errs)-1 {
+				writeTree(buf, e, prefix+"└─ ", prefix+"   ")
+			} else {
+				writeTree(buf, e, prefix+"├─ ", prefix+"│  ")
+			}
+		}
+		return
+	}
+}
+
+// FormatStable formats an error chain like the "%+v" verb, but with a deterministic rendering of the frames
+// suitable for golden files: the absolute file paths are replaced by paths relative to the package path,
+// such as "github.com/myorg/app/handler.go", so the output does not depend on the checkout directory.
+// If lines is false, the line numbers are elided too, so the output does not change when code is moved.
+// The output still depends on the frames of the Go runtime and standard library, which can be dropped
+// with [TrimFramePrefixes] or [StackPackagePrefix].
+func FormatStable(err error, lines bool) string {
+	var buf strings.Builder
+	writeErrorChain(&buf, err, func(frame Frame) string {
+		file := path.Base(frame.File)
+		if pkg := packagePath(frame.Function); pkg != "" {
+			file = pkg + "/" + file
+		}
+		if !lines {
+			return "> " + frame.Function + "\t" + file
+		}
+		return "> " + frame.Function + "\t" + file + ":" + strconv.Itoa(frame.Line)
+	}, -1, 0)
+	return truncateFormat(buf.String())
+}
+
+// packagePath returns the package path of a package path-qualified function name,
+// or an empty string if it cannot be determined.
+func packagePath(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+1+dot]
+}
+
+// FprintStack writes the error chain of err formatted like the "%+v" verb to w, and returns the first write error.
+// The output is streamed to w instead of being built in memory, which matters for large chains,
+// unless the output is limited by [SetMaxFormatBytes]. Nothing is written for a nil err.
+func FprintStack(w io.Writer, err error) error {
+	if err == nil {
+		return nil
+	}
+	if t, ok := err.(*temporary); ok { //nolint:errorlint
+		err = t.err
+	}
+	_, isBase := asBase(err)
+	_, isValue := asValue(err)
+	if !isBase && !isValue || maxFormatBytes.Load() > 0 {
+		_, e := fmt.Fprintf(w, "%+v", err)
+		return e
+	}
+	bw := bufio.NewWriter(w)
+	writeErrorChain(bw, err, FrameFormat, -1, 0)
+	return bw.Flush()
+}
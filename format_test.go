@@ -0,0 +1,209 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFormatJSONL(t *testing.T) {
+	err := Wrapf(Newf(msg), wrapper)
+	lines := strings.Split(strings.TrimSuffix(FormatJSONL(err), "\n"), "\n")
+
+	messages := 0
+	frames := 0
+	for _, line := range lines {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("expected valid JSON on every line, got %q: %v", line, err)
+		}
+		if _, ok := obj["message"]; ok {
+			messages++
+			continue
+		}
+		frames++
+		if obj["function"] == "" || obj["file"] == "" || obj["line"] == nil {
+			t.Fatalf("expected frame to carry function, file and line, got %q", line)
+		}
+	}
+	if messages != 2 {
+		t.Fatalf("expected 2 message lines, got %d", messages)
+	}
+	if frames == 0 {
+		t.Fatalf("expected frame lines")
+	}
+	if !strings.Contains(lines[1], `"function":"github.com/mawngo/go-errors.TestFormatJSONL"`) {
+		t.Fatalf("expected first frame to be the call site, got %q", lines[1])
+	}
+}
+
+func deepStack(depth int) error {
+	if depth == 0 {
+		return Newf(msg)
+	}
+	return deepStack(depth - 1)
+}
+
+func TestFormatWithinBudgetJoined(t *testing.T) {
+	out := FormatWithinBudget(Join(Newf("a"), Newf("b")), 100)
+	if !strings.HasPrefix(out, joinedIndent+"a\n"+joinedIndent+"> ") || !strings.Contains(out, "\n"+joinedIndent+"b\n") {
+		t.Fatalf("expected every joined error, got:\n%v", out)
+	}
+	if out := FormatWithinBudget(Wrapf(Join(Newf("a"), Newf("b")), wrapper), 3); out != wrapper+"\n"+joinedIndent+"a\n"+joinedIndent+"b\n" {
+		t.Fatalf("expected the messages of every joined error first, got:\n%v", out)
+	}
+}
+
+func TestFormatJSONLJoined(t *testing.T) {
+	out := FormatJSONL(Wrapf(Join(Newf("a"), Raw("b")), wrapper))
+	var messages []string
+	for line := range strings.Lines(out) {
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("expected valid JSON, got %v", err)
+		}
+		if message, ok := decoded["message"]; ok {
+			messages = append(messages, message.(string))
+		}
+	}
+	if strings.Join(messages, ",") != wrapper+",a,b" {
+		t.Fatalf("expected the messages of every joined error, got %q", messages)
+	}
+}
+
+func TestFormatWithinBudget(t *testing.T) {
+	err := Wrapf(deepStack(10), wrapper)
+	full := fmt.Sprintf("%+v", err)
+	if out := FormatWithinBudget(err, 100); out != full {
+		t.Fatalf("expected full output when within budget, got:\n%v", out)
+	}
+
+	out := FormatWithinBudget(err, 4)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d:\n%v", len(lines), out)
+	}
+	if lines[0] != wrapper || !strings.Contains(lines[1], "go-errors.TestFormatWithinBudget") {
+		t.Fatalf("expected the outer message followed by its top frame, got:\n%v", out)
+	}
+	if lines[2] != msg || !strings.Contains(lines[3], "go-errors.deepStack") {
+		t.Fatalf("expected the root message followed by the origin frame, got:\n%v", out)
+	}
+
+	out = FormatWithinBudget(err, 1)
+	if out != wrapper+"\n" {
+		t.Fatalf("expected messages to be kept first, got:\n%v", out)
+	}
+}
+
+func TestFormatTree(t *testing.T) {
+	err := Wrapf(Join(
+		Wrapf(Raw("connection refused"), "record a"),
+		Join(Newf("timeout"), ErrTest),
+	), "batch failed")
+
+	expected := `batch failed
+├─ record a
+│  connection refused
+└─ joined errors
+   ├─ timeout
+   └─ global_defined_error
+`
+	if out := FormatTree(err); out != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, out)
+	}
+
+	expected = "test_wrapper\ntest_error_message\n"
+	if out := FormatTree(Wrapf(Newf(msg), wrapper)); out != expected {
+		t.Fatalf("expected a single chain to render as a list, got:\n%v", out)
+	}
+	expected = "test_wrapper\nglobal_defined_error\n"
+	if out := FormatTree(Wrapw(ErrTest, errSentinel, wrapper)); out != expected {
+		t.Fatalf("expected the sentinel not to render as a branch, got:\n%v", out)
+	}
+	if out := FormatTree(nil); out != "" {
+		t.Fatalf("expected empty output for nil, got:\n%v", out)
+	}
+}
+
+func TestFormatStable(t *testing.T) {
+	TrimFramePrefixes = []string{"testing.", "runtime."}
+	defer func() {
+		TrimFramePrefixes = nil
+	}()
+
+	err := Wrapf(Newf(msg), wrapper)
+	expected := "test_wrapper\n" +
+		"> github.com/mawngo/go-errors.TestFormatStable\tgithub.com/mawngo/go-errors/format_test.go\n" +
+		"test_error_message\n" +
+		"> github.com/mawngo/go-errors.TestFormatStable\tgithub.com/mawngo/go-errors/format_test.go\n"
+	if out := FormatStable(err, false); out != expected {
+		t.Fatalf("expected:\n%v\ngot:\n%v", expected, out)
+	}
+
+	if out := FormatStable(err, true); !strings.Contains(out, "\tgithub.com/mawngo/go-errors/format_test.go:") {
+		t.Fatalf("expected the line numbers, got:\n%v", out)
+	}
+	if out := fmt.Sprintf("%+v", err); !strings.Contains(out, "/go-errors/format_test.go:") || strings.Contains(out, "\tgithub.com/") {
+		t.Fatalf("expected the default format to be untouched, got:\n%v", out)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, ErrTest
+}
+
+func TestFprintStack(t *testing.T) {
+	for i, err := range []error{
+		Wrapf(WithCode(Join(Newf("a"), Raw("b")), "code"), wrapper),
+		WithField(Newf(msg), "key", "value"),
+		AsTemporary(Newf(msg)),
+		ErrTest,
+	} {
+		var buf strings.Builder
+		if e := FprintStack(&buf, err); e != nil {
+			t.Fatalf("case %d: expected no error, got %v", i, e)
+		}
+		if expected := fmt.Sprintf("%+v", err); buf.String() != expected {
+			t.Fatalf("case %d: expected:\n%v\ngot:\n%v", i, expected, buf.String())
+		}
+	}
+
+	SetMaxFormatBytes(10)
+	defer SetMaxFormatBytes(0)
+	var buf strings.Builder
+	err := Wrapf(Newf(msg), wrapper)
+	if e := FprintStack(&buf, err); e != nil || buf.String() != fmt.Sprintf("%+v", err) {
+		t.Fatalf("expected the truncated output, got %v, %v", buf.String(), e)
+	}
+
+	if e := FprintStack(failingWriter{}, err); e != ErrTest { //nolint:errorlint
+		t.Fatalf("expected the write error, got %v", e)
+	}
+	if e := FprintStack(failingWriter{}, nil); e != nil {
+		t.Fatalf("expected nothing to be written for nil, got %v", e)
+	}
+}
+
+func TestTemplateMethods(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`{{.Message}}|{{.CauseMessage}}|{{range .StackLines}}{{.}};{{end}}`))
+	var out strings.Builder
+	if err := tmpl.Execute(&out, Wrapf(ErrTest, wrapper)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(out.String(), wrapper+"|"+ErrTest.Error()+"|> github.com/mawngo/go-errors.TestTemplateMethods\t") {
+		t.Fatalf("expected the message, the cause message and the stack lines, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := tmpl.Execute(&out, NewSentinel(msg)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if out.String() != msg+"||" {
+		t.Fatalf("expected zero values without cause and stacktrace, got %q", out.String())
+	}
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Group accumulates errors, for example the validation errors of several fields, to return them together.
+// The zero value is an empty group ready to use. A Group is not safe for concurrent use.
+//
+//	var g errors.Group
+//	if name == "" {
+//		g.Add(errors.WithCode(errors.Newf("name is required"), "required"))
+//	}
+//	if age < 0 {
+//		g.Addf("invalid age %d", age)
+//	}
+//	return g.Err()
+type Group struct {
+	errs []error
+}
+
+// Add adds err to the group. Nil errors are discarded.
+func (g *Group) Add(err error) {
+	if err != nil {
+		g.errs = append(g.errs, err)
+	}
+}
+
+// Addf adds a new error formatted like [Newf] to the group, with a stacktrace starting at the caller of Addf.
+func (g *Group) Addf(format string, args ...any) {
+	g.errs = append(g.errs, NewfSkip(1, format, args...))
+}
+
+// Len returns the number of errors in the group.
+func (g *Group) Len() int {
+	return len(g.errs)
+}
+
+// Err returns an error grouping the errors added so far, or nil if the group is empty.
+// The error implements the Unwrap() []error method, so each error of the group can be matched with [Is] and [As].
+// Its message is the messages of the errors separated by newlines, like [Join],
+// and the "%+v" verb prints the formatted chain of each error, including its stacktrace.
+// Errors added to the group afterwards are not included.
+func (g *Group) Err() error {
+	if len(g.errs) == 0 {
+		return nil
+	}
+	return &group{errs: slices.Clone(g.errs)}
+}
+
+// group is the error returned by [Group.Err].
+type group struct {
+	errs []error
+}
+
+// Error implements the error interface.
+func (g *group) Error() string {
+	return joinedMessage(g.errs)
+}
+
+// Unwrap implements the multi-error Unwrap interface.
+func (g *group) Unwrap() []error {
+	return g.errs
+}
+
+// Format implements the [fmt.Formatter] interface to support the formatting of the grouped errors with the "%+v" verb.
+func (g *group) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = s.Write([]byte(formatErrorChainState(s, g)))
+		return
+	}
+	_, _ = s.Write([]byte(g.Error()))
+}
@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	var g Group
+	if g.Err() != nil || g.Len() != 0 {
+		t.Fatalf("expected an empty group")
+	}
+
+	g.Add(nil)
+	g.Add(WithCode(ErrTest, "code"))
+	g.Addf("field %d", 1)
+	if g.Len() != 2 {
+		t.Fatalf("expected 2 errors, got %d", g.Len())
+	}
+	err := g.Err()
+	if err.Error() != ErrTest.Error()+"\nfield 1" {
+		t.Fatalf("expected the messages of the errors, got %q", err.Error())
+	}
+	if code, ok := Code(err.(interface{ Unwrap() []error }).Unwrap()[0]); !ok || code != "code" { //nolint:errorlint
+		t.Fatalf("expected the members to be exposed, got %q, %v", code, ok)
+	}
+	if !Is(err, ErrTest) {
+		t.Fatalf("expected the members to match with Is")
+	}
+	reg := regexp.MustCompile(`^    code=code\n    global_defined_error\n    field 1\n    > github\.com\/mawngo\/go-errors\.TestGroup	.*\/go-errors\/group_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected each error with its stacktrace, got:\n%+v", err)
+	}
+
+	g.Add(ErrTest)
+	if err.Error() != ErrTest.Error()+"\nfield 1" {
+		t.Fatalf("expected the returned error not to change, got %q", err.Error())
+	}
+}
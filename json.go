@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// jsonError is the JSON representation of an error chain.
+type jsonError struct {
+	// Message is the message of this error only.
+	Message string `json:"message"`
+	// Metadata contains the metadata attached to this error.
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// Fields contains the fields attached to this error.
+	Fields map[string]any `json:"fields,omitempty"`
+	// CreatedAt is the time this error was created, see CaptureTime.
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	// Stack contains the frames of the stacktrace of this error.
+	Stack []jsonFrame `json:"stack,omitempty"`
+	// Cause is the wrapped error, either a *jsonError or the message of an error not created by this package.
+	Cause any `json:"cause,omitempty"`
+	// Causes are the errors joined by this error, such as with Join, each either a *jsonError or a message.
+	Causes []any `json:"causes,omitempty"`
+}
+
+// jsonFrame is the JSON representation of a stack frame.
+type jsonFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+// The error chain is encoded as nested objects with the "message", "stack" and "cause" keys,
+// see [MarshalJSON].
+func (b *base) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONError(b, 0))
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (v *withValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONError(v, 0))
+}
+
+// MarshalJSON encodes any error as JSON, for structured logging.
+// Errors created by this package are encoded as an object with their "message", their "stack"
+// as an array of {"func", "file", "line"} objects, their attached "metadata" and "fields" if any,
+// their "created_at" time if recorded (see [CaptureTime]), and their "cause",
+// which is either a nested object or the message of an error not created by this package.
+// Joined errors, such as returned by [Join], are encoded with their message and their "causes" array.
+// Other errors are encoded as {"message": err.Error()}, and nil is encoded as null.
+func MarshalJSON(err error) ([]byte, error) {
+	if err == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(newJSONError(err, 0))
+}
+
+// newJSONError converts an error chain into its JSON representation.
+// depth is the number of errors of the chain already converted.
+func newJSONError(err error, depth int) *jsonError {
+	out := &jsonError{}
+	for ; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok {
+			values := &out.Metadata
+			if v.field {
+				values = &out.Fields
+			}
+			if *values == nil {
+				*values = make(map[string]any)
+			}
+			if _, ok := (*values)[string(v.key)]; !ok {
+				(*values)[string(v.key)] = v.value
+			}
+			err = v.err
+			continue
+		}
+		if errs, ok := joinedErrors(err); ok {
+			out.Message = err.Error()
+			for _, e := range errs {
+				out.Causes = append(out.Causes, newJSONCause(e, depth+1))
+			}
+			return out
+		}
+		e, steps, ok := findBase(err, MaxChainDepth-depth)
+		if !ok {
+			out.Message = err.Error()
+			return out
+		}
+		depth += steps
+		out.Message = e.message()
+		out.CreatedAt = e.stack.created
+		for _, frame := range e.stack.frames() {
+			out.Stack = append(out.Stack, jsonFrame{Func: frame.Function, File: frame.File, Line: frame.Line})
+		}
+		if e.err != nil {
+			out.Cause = newJSONCause(e.err, depth+1)
+		}
+		return out
+	}
+	return out
+}
+
+// newJSONCause converts the chain of a cause into its JSON representation,
+// which is only its message if it carries nothing else.
+func newJSONCause(err error, depth int) any {
+	cause := newJSONError(err, depth)
+	if cause.Metadata == nil && cause.Fields == nil && cause.CreatedAt.IsZero() && cause.Stack == nil && cause.Cause == nil && cause.Causes == nil {
+		return cause.Message
+	}
+	return cause
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	err := Wrapf(WithRequestID(Wrapf(ErrTest, wrapper), "req-1"), "outer")
+
+	out, e := json.Marshal(err)
+	if e != nil {
+		t.Fatalf("expected no error, got %v", e)
+	}
+	var decoded struct {
+		Message string `json:"message"`
+		Stack   []struct {
+			Func string `json:"func"`
+			File string `json:"file"`
+			Line int    `json:"line"`
+		} `json:"stack"`
+		Cause struct {
+			Message  string         `json:"message"`
+			Metadata map[string]any `json:"metadata"`
+			Cause    string         `json:"cause"`
+		} `json:"cause"`
+	}
+	if e := json.Unmarshal(out, &decoded); e != nil {
+		t.Fatalf("expected valid JSON, got %v: %s", e, out)
+	}
+	if decoded.Message != "outer" || decoded.Cause.Message != wrapper || decoded.Cause.Cause != ErrTest.Error() {
+		t.Fatalf("expected the message chain, got %s", out)
+	}
+	if decoded.Cause.Metadata["request_id"] != "req-1" {
+		t.Fatalf("expected the request ID metadata, got %s", out)
+	}
+	if len(decoded.Stack) == 0 || decoded.Stack[0].Func != "github.com/mawngo/go-errors.TestMarshalJSON" ||
+		decoded.Stack[0].File == "" || decoded.Stack[0].Line == 0 {
+		t.Fatalf("expected the stack frames, got %s", out)
+	}
+
+	for i, tc := range []struct {
+		err      error
+		expected string
+	}{
+		{err: ErrTest, expected: `{"message":"global_defined_error"}`},
+		{err: nil, expected: `null`},
+		{err: &base{info: msg}, expected: `{"message":"test_error_message"}`},
+	} {
+		out, e := MarshalJSON(tc.err)
+		if e != nil || string(out) != tc.expected {
+			t.Fatalf("case %d: expected %s, got %s, %v", i, tc.expected, out, e)
+		}
+	}
+}
+
+// selfWrapping is an error that wraps itself.
+type selfWrapping struct{}
+
+func (e *selfWrapping) Error() string { return "self" }
+func (e *selfWrapping) Unwrap() error { return Wrapf(e, wrapper) }
+
+func TestMarshalJSONCycle(t *testing.T) {
+	if _, err := MarshalJSON(Wrapf(&selfWrapping{}, wrapper)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestMarshalJSONFields(t *testing.T) {
+	out, err := MarshalJSON(WithField(Wrapf(WithField(ErrTest, "inner", 1), wrapper), "outer", "value"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var decoded struct {
+		Fields map[string]any `json:"fields"`
+		Cause  struct {
+			Fields map[string]any `json:"fields"`
+		} `json:"cause"`
+	}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %v: %s", err, out)
+	}
+	if decoded.Fields["outer"] != "value" || decoded.Cause.Fields["inner"] != float64(1) {
+		t.Fatalf("expected the fields near their error, got %s", out)
+	}
+}
+
+func TestMarshalJSONCreatedAt(t *testing.T) {
+	CaptureTime = true
+	defer func() {
+		CaptureTime = false
+	}()
+	out, err := MarshalJSON(Newf(msg))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if _, ok := decoded["created_at"]; !ok {
+		t.Fatalf("expected the creation time, got %s", out)
+	}
+
+	CaptureTime = false
+	if out, _ := MarshalJSON(Newf(msg)); strings.Contains(string(out), "created_at") {
+		t.Fatalf("expected no creation time, got %s", out)
+	}
+}
+
+func TestMarshalJSONJoined(t *testing.T) {
+	var decoded struct {
+		Message string `json:"message"`
+		Cause   struct {
+			Message string `json:"message"`
+			Causes  []struct {
+				Message string `json:"message"`
+			} `json:"causes"`
+		} `json:"cause"`
+	}
+	out, err := MarshalJSON(Wrapf(Join(Newf("a"), Newf("b")), "outer"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if causes := decoded.Cause.Causes; len(causes) != 2 || causes[0].Message != "a" || causes[1].Message != "b" {
+		t.Fatalf("expected every joined error, got %s", out)
+	}
+
+	out, _ = MarshalJSON(Join(Newf("a"), Raw("b")))
+	if !strings.Contains(string(out), `"message":"a"`) || !strings.Contains(string(out), `"causes":[{`) || !strings.HasSuffix(string(out), `"b"]}`) {
+		t.Fatalf("expected every joined error, got %s", out)
+	}
+}
@@ -0,0 +1,443 @@
+package errors
+
+import (
+	//lint:ignore faillint Custom errors package needs to import standard library errors.
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// metaKey identifies a metadata attached to an error, and is used as its name when formatting.
+type metaKey string
+
+const (
+	requestIDKey   metaKey = "request_id"
+	recordIDKey    metaKey = "record_id"
+	recordIndexKey metaKey = "record_index"
+	codeKey        metaKey = "code"
+	httpStatusKey  metaKey = "http_status"
+	retryableKey   metaKey = "retryable"
+	severityKey    metaKey = "severity"
+	exitCodeKey    metaKey = "exit_code"
+)
+
+// withValue attaches a metadata value to an error without altering its message or stacktrace.
+type withValue struct {
+	// err is the error the metadata is attached to.
+	err error
+	// key identifies the metadata.
+	key metaKey
+	// field reports whether the metadata is a user defined field, see WithField.
+	field bool
+	// value is the metadata value.
+	value any
+}
+
+// Error implements the error interface.
+func (v *withValue) Error() string {
+	return v.err.Error()
+}
+
+// Unwrap implements the error Unwrap interface.
+func (v *withValue) Unwrap() error {
+	return v.err
+}
+
+// Format implements the [fmt.Formatter] interface to support the formatting of an error chain with the "%+v" verb.
+// Attached metadata are printed as key=value lines in the error chain.
+func (v *withValue) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = s.Write([]byte(formatErrorChainState(s, v)))
+		return
+	}
+	if verb == 'v' && s.Flag('#') {
+		_, _ = s.Write([]byte(v.GoString()))
+		return
+	}
+	_, _ = s.Write([]byte(v.Error()))
+}
+
+// GoString implements the [fmt.GoStringer] interface.
+func (v *withValue) GoString() string {
+	return goString(v, 0)
+}
+
+// Is implements the errors.Is interface, so that errors marked by [MarkRetryable] match [ErrRetryable].
+func (v *withValue) Is(target error) bool {
+	return !v.field && v.key == retryableKey && target == ErrRetryable //nolint:errorlint
+}
+
+// asValue reports whether err itself, not its chain, is a metadata attached by this package.
+func asValue(err error) (*withValue, bool) {
+	v, ok := err.(*withValue) //nolint:errorlint
+	return v, ok
+}
+
+// withMeta attaches the metadata value identified by key to err.
+func withMeta(err error, key metaKey, value any) error {
+	if err == nil {
+		return nil
+	}
+	return &withValue{
+		err:   err,
+		key:   key,
+		value: value,
+	}
+}
+
+// lookupMeta returns the outermost metadata value identified by key in the error chain.
+func lookupMeta(err error, key metaKey) (any, bool) {
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok && !v.field && v.key == key {
+			return v.value, true
+		}
+		err = unwrapChain(err)
+	}
+	return nil, false
+}
+
+// WithRequestID attaches a request correlation ID to the error.
+// The request ID survives further wrapping and is printed by the "%+v" verb.
+// When multiple request IDs are attached in the chain, the outermost one wins.
+//
+// If err is nil, this method returns nil.
+func WithRequestID(err error, id string) error {
+	return withMeta(err, requestIDKey, id)
+}
+
+// RequestID returns the outermost request correlation ID attached to the error chain.
+func RequestID(err error) (string, bool) {
+	v, ok := lookupMeta(err, requestIDKey)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// WithCode attaches a stable code to the error, for example to map internal errors to API responses.
+// The code survives further wrapping, and the error still matches its chain with [Is] and [As].
+// When multiple codes are attached in the chain, the outermost one wins.
+//
+// If err is nil, this method returns nil.
+func WithCode(err error, code string) error {
+	return withMeta(err, codeKey, code)
+}
+
+// Code returns the outermost code attached to the error chain.
+func Code(err error) (string, bool) {
+	v, ok := lookupMeta(err, codeKey)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// WithHTTPStatus associates an HTTP status code to the error, for example to translate errors
+// into HTTP responses in a middleware after they were wrapped several times.
+// When multiple statuses are associated in the chain, the outermost one wins.
+//
+// If err is nil, this method returns nil.
+func WithHTTPStatus(err error, status int) error {
+	return withMeta(err, httpStatusKey, status)
+}
+
+// HTTPStatus returns the outermost HTTP status code associated to the error chain.
+// It returns false if no status is associated.
+func HTTPStatus(err error) (int, bool) {
+	v, ok := lookupMeta(err, httpStatusKey)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+// StatusOr returns the outermost HTTP status code associated to the error chain, or fallback if none is associated.
+func StatusOr(err error, fallback int) int {
+	if status, ok := HTTPStatus(err); ok {
+		return status
+	}
+	return fallback
+}
+
+// WithExitCode associates the exit code the process should terminate with to the error,
+// for command line applications, see [ExitCode].
+// When multiple exit codes are associated in the chain, the outermost one wins.
+//
+// If err is nil, this method returns nil.
+func WithExitCode(err error, code int) error {
+	return withMeta(err, exitCodeKey, code)
+}
+
+// ExitCode returns the outermost exit code associated to the error chain, 1 if none is associated,
+// or 0 if err is nil, so that a program can terminate with
+//
+//	os.Exit(errors.ExitCode(run()))
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if v, ok := lookupMeta(err, exitCodeKey); ok {
+		return v.(int)
+	}
+	return 1
+}
+
+// ErrRetryable is matched by errors marked by [MarkRetryable], so that
+//
+//	errors.Is(err, errors.ErrRetryable)
+//
+// reports whether the operation that failed with err can be retried.
+var ErrRetryable = errors.New("retryable")
+
+// MarkRetryable marks the error as transient, so that callers can decide to retry the operation that failed.
+// The mark survives further wrapping, see [IsRetryable].
+//
+// If err is nil, this method returns nil.
+func MarkRetryable(err error) error {
+	return withMeta(err, retryableKey, true)
+}
+
+// IsRetryable reports whether the error was marked by [MarkRetryable], or wraps [ErrRetryable].
+// It returns false for nil.
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRetryable)
+}
+
+// AsTemporary wraps err so that it implements the Temporary() bool method of the deprecated [net.Error],
+// reporting true. This lets errors of this package interoperate with retry libraries sniffing for it.
+// The message, the cause, and the "%+v" output including the stacktrace are those of err.
+//
+// If err is nil, this method returns nil.
+func AsTemporary(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &temporary{err: err}
+}
+
+// temporary implements the Temporary() bool method for errors wrapped by [AsTemporary].
+type temporary struct {
+	err error
+}
+
+// Error implements the error interface.
+func (t *temporary) Error() string {
+	return t.err.Error()
+}
+
+// Unwrap implements the error Unwrap interface.
+func (t *temporary) Unwrap() error {
+	return t.err
+}
+
+// Temporary reports that the error is temporary.
+func (t *temporary) Temporary() bool {
+	return true
+}
+
+// Format implements the [fmt.Formatter] interface by formatting the wrapped error.
+func (t *temporary) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		_, _ = s.Write([]byte(formatErrorChainState(s, t.err)))
+		return
+	}
+	if verb == 'v' && s.Flag('#') {
+		_, _ = s.Write([]byte(goString(t.err, 0)))
+		return
+	}
+	_, _ = s.Write([]byte(t.Error()))
+}
+
+// IsTimeout reports whether any error in the chain of err, including joined errors,
+// implements the Timeout() bool method of [net.Error] and reports a timeout.
+// Unlike a type assertion on err, it sees through the wrappers that do not forward the method.
+// It returns false for nil.
+func IsTimeout(err error) bool {
+	found := false
+	Walk(err, func(err error) bool {
+		t, ok := err.(interface{ Timeout() bool }) //nolint:errorlint
+		found = ok && t.Timeout()
+		return !found
+	})
+	return found
+}
+
+// IsTemporary reports whether any error in the chain of err, including joined errors,
+// implements the Temporary() bool method of the deprecated [net.Error] and reports a temporary error,
+// such as the errors returned by [AsTemporary].
+// Unlike a type assertion on err, it sees through the wrappers that do not forward the method.
+// It returns false for nil.
+func IsTemporary(err error) bool {
+	found := false
+	Walk(err, func(err error) bool {
+		t, ok := err.(interface{ Temporary() bool }) //nolint:errorlint
+		found = ok && t.Temporary()
+		return !found
+	})
+	return found
+}
+
+// Severity is the level of an error, used to route errors for alerting, see [WithSeverity].
+type Severity int8
+
+const (
+	// SeverityUnknown is the severity of errors without severity attached.
+	SeverityUnknown Severity = iota
+	// SeverityWarning is the severity of errors that do not need immediate attention.
+	SeverityWarning
+	// SeverityError is the severity of errors that need attention.
+	SeverityError
+	// SeverityFatal is the severity of errors the program cannot recover from.
+	SeverityFatal
+)
+
+// String returns the lower case name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements the [encoding.TextMarshaler] interface, so that the severity is encoded by its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// WithSeverity attaches a severity level to the error.
+// The severity survives further wrapping and is printed by the "%+v" verb.
+// When multiple severities are attached in the chain, the outermost one wins.
+//
+// If err is nil, this method returns nil.
+func WithSeverity(err error, severity Severity) error {
+	return withMeta(err, severityKey, severity)
+}
+
+// SeverityOf returns the severity attached to the error chain, see [WithSeverity].
+// It returns [SeverityUnknown] if no severity is attached.
+func SeverityOf(err error) Severity {
+	v, ok := lookupMeta(err, severityKey)
+	if !ok {
+		return SeverityUnknown
+	}
+	return v.(Severity)
+}
+
+// WrapRecord returns a new error wrapping cause with a stacktrace containing recent call frames,
+// a message identifying the record being processed, and the record ID and index attached as metadata.
+// This standardizes record-level context for data pipelines,
+// the record can be retrieved with [Record] and is printed by the "%+v" verb.
+//
+// If the cause is nil, this method returns nil.
+func WrapRecord(cause error, recordID string, index int) error {
+	if cause == nil {
+		return nil
+	}
+	info := fmt.Sprintf("record %s at index %d", recordID, index)
+	err := &base{
+		info:  info,
+		stack: newStackTrace(info),
+		err:   cause,
+	}
+	return withMeta(withMeta(err, recordIndexKey, index), recordIDKey, recordID)
+}
+
+// Record returns the ID and index of the outermost record attached to the error chain by [WrapRecord].
+func Record(err error) (string, int, bool) {
+	id, ok := lookupMeta(err, recordIDKey)
+	if !ok {
+		return "", 0, false
+	}
+	index, _ := lookupMeta(err, recordIndexKey)
+	return id.(string), index.(int), true
+}
+
+// WithField attaches a structured key-value field to the error, without changing its message or stacktrace.
+// Fields survive further wrapping, and are printed by the "%+v" verb and included in the JSON
+// and [log/slog] outputs. Use [Fields] to retrieve them.
+//
+// If err is nil, this method returns nil.
+func WithField(err error, key string, value any) error {
+	if err == nil {
+		return nil
+	}
+	return &withValue{
+		err:   err,
+		key:   metaKey(key),
+		value: value,
+		field: true,
+	}
+}
+
+// WithFields attaches multiple structured key-value fields to the error, see [WithField].
+// Fields are attached in the sorted order of their keys.
+//
+// If err is nil, this method returns nil.
+func WithFields(err error, kv map[string]any) error {
+	keys := make([]string, 0, len(kv))
+	for key := range kv {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for i := len(keys) - 1; i >= 0; i-- {
+		err = WithField(err, keys[i], kv[keys[i]])
+	}
+	return err
+}
+
+// Fields returns the fields attached to the error chain, merged into a single map.
+// When the same key is attached multiple times in the chain, the outermost value wins.
+// It returns nil if no field is attached.
+func Fields(err error) map[string]any {
+	var fields map[string]any
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok && v.field {
+			if fields == nil {
+				fields = make(map[string]any)
+			}
+			if _, ok := fields[string(v.key)]; !ok {
+				fields[string(v.key)] = v.value
+			}
+		}
+		err = unwrapChain(err)
+	}
+	return fields
+}
+
+// GetField returns the value of the outermost field identified by key in the error chain, see [WithField].
+// It returns the zero value and false if the field is missing or if its value is not of type T.
+func GetField[T any](err error, key string) (T, bool) {
+	var zero T
+	value, ok := lookupField(err, key)
+	if !ok {
+		return zero, false
+	}
+	t, ok := value.(T)
+	if !ok {
+		return zero, false
+	}
+	return t, true
+}
+
+// HasField reports whether a field identified by key is attached to the error chain, see [WithField].
+func HasField(err error, key string) bool {
+	_, ok := lookupField(err, key)
+	return ok
+}
+
+// lookupField returns the value of the outermost field identified by key in the error chain.
+func lookupField(err error, key string) (any, bool) {
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok && v.field && v.key == metaKey(key) {
+			return v.value, true
+		}
+		err = unwrapChain(err)
+	}
+	return nil, false
+}
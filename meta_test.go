@@ -0,0 +1,297 @@
+package errors
+
+import (
+	//lint:ignore faillint Custom errors package tests need to import standard library errors.
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	err := WithRequestID(Newf(msg), "req-1")
+	if err.Error() != msg {
+		t.Fatalf("expected the message to be unchanged, got %q", err.Error())
+	}
+	err = Wrapf(Wrapf(err, wrapper), wrapper)
+	if id, ok := RequestID(err); !ok || id != "req-1" {
+		t.Fatalf("expected the request ID to survive wrapping, got %q, %v", id, ok)
+	}
+
+	err = WithRequestID(err, "req-2")
+	if id, _ := RequestID(err); id != "req-2" {
+		t.Fatalf("expected the outermost request ID to win, got %q", id)
+	}
+	if !stderrors.Is(Wrapf(WithRequestID(ErrTest, "req-3"), wrapper), ErrTest) {
+		t.Fatalf("expected the chain to remain traversable")
+	}
+
+	reg := regexp.MustCompile(`^request_id=req-2
+test_wrapper
+[[:ascii:]]+request_id=req-1
+test_error_message[ \n]+> github\.com\/mawngo\/go-errors\.TestRequestID	`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the request IDs in the formatted chain, got:\n%+v", err)
+	}
+
+	if _, ok := RequestID(Newf(msg)); ok {
+		t.Fatalf("expected no request ID")
+	}
+	if WithRequestID(nil, "req-1") != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+func TestWrapRecord(t *testing.T) {
+	err := Wrapf(WrapRecord(ErrTest, "user-42", 3), wrapper)
+	if err.Error() != wrapper+": record user-42 at index 3: "+ErrTest.Error() {
+		t.Fatalf("expected the record message, got %q", err.Error())
+	}
+	id, index, ok := Record(err)
+	if !ok || id != "user-42" || index != 3 {
+		t.Fatalf("expected the record to be retrievable, got %q, %d, %v", id, index, ok)
+	}
+
+	reg := regexp.MustCompile(`record_id=user-42
+record_index=3
+record user-42 at index 3[ \n]+> github\.com\/mawngo\/go-errors\.TestWrapRecord	`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the record in the formatted chain, got:\n%+v", err)
+	}
+
+	if _, _, ok := Record(ErrTest); ok {
+		t.Fatalf("expected no record")
+	}
+	if WrapRecord(nil, "user-42", 3) != nil {
+		t.Fatalf("expected nil for nil cause")
+	}
+}
+
+func TestMetaWrapw(t *testing.T) {
+	err := Wrapw(WithField(WithHTTPStatus(WithCode(Newf("root"), "c1"), http.StatusNotFound), "k", "v"), errSentinel, wrapper)
+	if code, ok := Code(err); !ok || code != "c1" {
+		t.Fatalf("expected the code below Wrapw, got %q, %v", code, ok)
+	}
+	if status, ok := HTTPStatus(err); !ok || status != http.StatusNotFound {
+		t.Fatalf("expected the status below Wrapw, got %d, %v", status, ok)
+	}
+	if fields := Fields(err); fields["k"] != "v" {
+		t.Fatalf("expected the fields below Wrapw, got %v", fields)
+	}
+	if value, ok := GetField[string](err, "k"); !ok || value != "v" {
+		t.Fatalf("expected the field below Wrapw, got %q, %v", value, ok)
+	}
+}
+
+func TestFields(t *testing.T) {
+	err := WithField(Newf(msg), "user_id", 1)
+	err = Wrapf(WithFields(err, map[string]any{"user_id": 42, "action": "login"}), wrapper)
+	if err.Error() != wrapper+": "+msg {
+		t.Fatalf("expected the message to be unchanged, got %q", err.Error())
+	}
+
+	fields := Fields(WithRequestID(err, "req-1"))
+	if len(fields) != 2 || fields["user_id"] != 42 || fields["action"] != "login" {
+		t.Fatalf("expected the outermost fields to win, got %v", fields)
+	}
+	if _, ok := RequestID(WithField(ErrTest, "request_id", "field")); ok {
+		t.Fatalf("expected fields not to be mistaken for metadata")
+	}
+
+	reg := regexp.MustCompile(`^test_wrapper
+[[:ascii:]]+action=login
+user_id=42
+user_id=1
+test_error_message[ \n]+> github\.com\/mawngo\/go-errors\.TestFields	`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the fields in the formatted chain, got:\n%+v", err)
+	}
+
+	if Fields(Newf(msg)) != nil {
+		t.Fatalf("expected no fields")
+	}
+	if WithField(nil, "key", 1) != nil || WithFields(nil, map[string]any{"key": 1}) != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+func TestCode(t *testing.T) {
+	err := Wrapf(WithCode(Wrapf(ErrTest, wrapper), "not_found"), wrapper)
+	if code, ok := Code(err); !ok || code != "not_found" {
+		t.Fatalf("expected the code to survive wrapping, got %q, %v", code, ok)
+	}
+	if !stderrors.Is(err, ErrTest) {
+		t.Fatalf("expected the chain to match with Is")
+	}
+	var e *base
+	if !stderrors.As(err, &e) || e.info != wrapper {
+		t.Fatalf("expected the chain to match with As")
+	}
+	if code, _ := Code(WithCode(err, "invalid")); code != "invalid" {
+		t.Fatalf("expected the outermost code to win, got %q", code)
+	}
+	if _, ok := Code(ErrTest); ok {
+		t.Fatalf("expected no code")
+	}
+	if WithCode(nil, "invalid") != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	err := Wrapf(Wrapf(WithHTTPStatus(Newf(msg), http.StatusNotFound), wrapper), wrapper)
+	if status, ok := HTTPStatus(err); !ok || status != http.StatusNotFound {
+		t.Fatalf("expected the status to survive wrapping, got %d, %v", status, ok)
+	}
+	if status := StatusOr(WithHTTPStatus(err, http.StatusBadRequest), http.StatusInternalServerError); status != http.StatusBadRequest {
+		t.Fatalf("expected the outermost status to win, got %d", status)
+	}
+	if _, ok := HTTPStatus(ErrTest); ok {
+		t.Fatalf("expected no status")
+	}
+	if status := StatusOr(ErrTest, http.StatusInternalServerError); status != http.StatusInternalServerError {
+		t.Fatalf("expected the fallback status, got %d", status)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	err := Wrapf(Wrapf(WithExitCode(Newf(msg), 2), wrapper), wrapper)
+	if code := ExitCode(err); code != 2 {
+		t.Fatalf("expected the exit code to survive wrapping, got %d", code)
+	}
+	if code := ExitCode(WithExitCode(err, 3)); code != 3 {
+		t.Fatalf("expected the outermost exit code to win, got %d", code)
+	}
+	if code := ExitCode(ErrTest); code != 1 {
+		t.Fatalf("expected the default exit code, got %d", code)
+	}
+	if code := ExitCode(nil); code != 0 {
+		t.Fatalf("expected no exit code for nil, got %d", code)
+	}
+	if WithExitCode(nil, 2) != nil {
+		t.Fatalf("expected nil")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	err := Wrapf(Wrapf(MarkRetryable(Newf(msg)), wrapper), wrapper)
+	if !IsRetryable(err) || !stderrors.Is(err, ErrRetryable) {
+		t.Fatalf("expected the mark to survive wrapping")
+	}
+	if err.Error() != wrapper+": "+wrapper+": "+msg {
+		t.Fatalf("expected the message to be unchanged, got %q", err.Error())
+	}
+	if IsRetryable(Wrapf(ErrTest, wrapper)) || IsRetryable(WithCode(ErrTest, "code")) {
+		t.Fatalf("expected unmarked errors not to be retryable")
+	}
+	if IsRetryable(nil) {
+		t.Fatalf("expected nil not to be retryable")
+	}
+	if MarkRetryable(nil) != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+func TestGetField(t *testing.T) {
+	err := Wrapf(WithField(WithField(Newf(msg), "user_id", "inner"), "user_id", 42), wrapper)
+	if v, ok := GetField[int](err, "user_id"); !ok || v != 42 {
+		t.Fatalf("expected the outermost field, got %v, %v", v, ok)
+	}
+	if v, ok := GetField[string](err, "user_id"); ok || v != "" {
+		t.Fatalf("expected the zero value for a mismatched type, got %q, %v", v, ok)
+	}
+	if _, ok := GetField[int](err, "missing"); ok {
+		t.Fatalf("expected no value for a missing key")
+	}
+	if !HasField(err, "user_id") || HasField(err, "missing") {
+		t.Fatalf("unexpected HasField result")
+	}
+	if HasField(WithCode(ErrTest, "code"), string(codeKey)) {
+		t.Fatalf("expected metadata not to be mistaken for fields")
+	}
+}
+
+func TestAsTemporary(t *testing.T) {
+	cause := Wrapf(ErrTest, wrapper)
+	err := AsTemporary(cause)
+
+	var temporary interface{ Temporary() bool }
+	if !As(Wrapf(err, wrapper), &temporary) || !temporary.Temporary() {
+		t.Fatalf("expected the error to be temporary")
+	}
+	if err.Error() != cause.Error() || !Is(err, ErrTest) {
+		t.Fatalf("expected the error to delegate to the wrapped error, got %v", err)
+	}
+	if out := fmt.Sprintf("%+v", err); out != fmt.Sprintf("%+v", cause) {
+		t.Fatalf("expected the stacktrace to be kept, got:\n%v", out)
+	}
+	if AsTemporary(nil) != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	err := Wrapf(WithSeverity(WithSeverity(Newf(msg), SeverityFatal), SeverityWarning), wrapper)
+	if sev := SeverityOf(err); sev != SeverityWarning {
+		t.Fatalf("expected the outermost severity, got %v", sev)
+	}
+	if sev := SeverityOf(ErrTest); sev != SeverityUnknown {
+		t.Fatalf("expected an unknown severity, got %v", sev)
+	}
+	if WithSeverity(nil, SeverityError) != nil {
+		t.Fatalf("expected nil for nil error")
+	}
+
+	reg := regexp.MustCompile(`^test_wrapper\n[[:ascii:]]+severity=warning\nseverity=fatal\ntest_error_message\n`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the severity in the formatted chain, got:\n%+v", err)
+	}
+
+	out, e := MarshalJSON(err)
+	if e != nil || !strings.Contains(string(out), `"severity":"warning"`) {
+		t.Fatalf("expected the severity in the JSON output, got %s, %v", out, e)
+	}
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", "err", err)
+	if !strings.Contains(buf.String(), `"severity":"warning"`) {
+		t.Fatalf("expected the severity in the log record, got %s", buf.String())
+	}
+}
+
+type timeoutError struct {
+	timeout bool
+}
+
+func (e timeoutError) Error() string   { return "timeout" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return e.timeout }
+
+func TestIsTimeout(t *testing.T) {
+	_, cause := net.Dial("tcp", "invalid address")
+	if IsTimeout(Wrapf(cause, wrapper)) || IsTemporary(Wrapf(cause, wrapper)) {
+		t.Fatalf("expected a dial error not to be a timeout")
+	}
+
+	err := Wrapf(WithCode(timeoutError{timeout: true}, "code"), wrapper)
+	if !IsTimeout(err) || !IsTemporary(err) {
+		t.Fatalf("expected the timeout to be detected through the wrappers")
+	}
+	err = Wrapf(Join(timeoutError{timeout: false}, Wrapf(timeoutError{timeout: true}, wrapper)), wrapper)
+	if !IsTimeout(err) || !IsTemporary(err) {
+		t.Fatalf("expected the timeout to be detected in joined errors")
+	}
+	if IsTimeout(Wrapf(timeoutError{timeout: false}, wrapper)) || IsTemporary(Wrapf(ErrTest, wrapper)) {
+		t.Fatalf("expected no timeout")
+	}
+	if !IsTemporary(Wrapf(AsTemporary(ErrTest), wrapper)) {
+		t.Fatalf("expected AsTemporary errors to be temporary")
+	}
+	if IsTimeout(nil) || IsTemporary(nil) {
+		t.Fatalf("expected false for nil")
+	}
+}
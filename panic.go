@@ -0,0 +1,123 @@
+package errors
+
+import (
+	"fmt"
+)
+
+// PanicError is the panic value of the helpers of this package that panic, such as [Must] and [Assertf],
+// so that a deferred recover can tell intentional panics from runtime panics, see [AsPanic].
+// It wraps an error of this package carrying the stacktrace of the panic site,
+// which is printed by the "%+v" verb.
+type PanicError struct {
+	err *base
+}
+
+// Error implements the error interface.
+func (p *PanicError) Error() string {
+	return p.err.Error()
+}
+
+// Unwrap implements the error Unwrap interface.
+func (p *PanicError) Unwrap() error {
+	return p.err
+}
+
+// Format implements the [fmt.Formatter] interface, formatting the wrapped error.
+func (p *PanicError) Format(s fmt.State, verb rune) {
+	p.err.Format(s, verb)
+}
+
+// AsPanic reports whether v, a value returned by recover, was raised by a helper of this package such as [Must]
+// or [Assertf]. Runtime panics and panics with other values do not match.
+//
+//	defer func() {
+//		if p, ok := errors.AsPanic(recover()); ok {
+//			log.Printf("%+v", p)
+//		}
+//	}()
+func AsPanic(v any) (*PanicError, bool) {
+	p, ok := v.(*PanicError)
+	return p, ok
+}
+
+// Guard runs fn and returns its error. If fn panics, the panic is recovered and returned as an error
+// with a stacktrace pointing at the panic site, turning panicking code into error-returning code.
+// A panic value that is an error is wrapped, so it can still be matched with [Is] and [As].
+func Guard(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fromPanic(r, newPanicStackTrace())
+		}
+	}()
+	return fn()
+}
+
+// Recover converts a panic in flight into an error with a stacktrace pointing at the panic site
+// and assigns it to *errp. It must be called directly by defer:
+//
+//	func handle() (err error) {
+//		defer errors.Recover(&err)
+//		...
+//	}
+//
+// A panic value that is an error is wrapped, so it can still be matched with [Is] and [As].
+// If there is no panic, *errp is left untouched.
+func Recover(errp *error) {
+	if r := recover(); r != nil {
+		*errp = fromPanic(r, newPanicStackTrace())
+	}
+}
+
+// fromPanic converts a recovered panic value into an error carrying the given stacktrace.
+func fromPanic(v any, stack stacktrace) error {
+	if err, ok := v.(error); ok {
+		return &base{
+			info:  "panic",
+			stack: stack,
+			err:   err,
+		}
+	}
+	return &base{
+		info:  fmt.Sprintf("panic: %v", v),
+		stack: stack,
+		err:   nil,
+	}
+}
+
+// Must returns v if err is nil, otherwise it panics with err wrapped with a stacktrace
+// pointing at the caller of Must. It is intended for initialization code, such as:
+//
+//	port := errors.Must(strconv.Atoi(os.Getenv("PORT")))
+//
+// The panic value is a [*PanicError], so a deferred recover can detect it with [AsPanic] and print it with "%+v".
+func Must[T any](v T, err error) T {
+	if err != nil {
+		panic(&PanicError{err: mustError(err)})
+	}
+	return v
+}
+
+// Must0 is like [Must] for functions returning only an error.
+func Must0(err error) {
+	if err != nil {
+		panic(&PanicError{err: mustError(err)})
+	}
+}
+
+// Assertf panics with a [*PanicError] wrapping a new error formatted like [Newf] if cond is false, with a stacktrace starting
+// at the caller of Assertf. It is intended for invariants that cannot be violated unless there is a bug,
+// use [Check] to validate inputs.
+func Assertf(cond bool, format string, args ...any) {
+	if !cond {
+		panic(&PanicError{err: NewfSkip(1, format, args...).(*base)})
+	}
+}
+
+// mustError wraps err with a stacktrace starting at the caller of [Must] or [Must0].
+func mustError(err error) *base {
+	return &base{
+		info:  err.Error(),
+		stack: newStackTraceSkip(1, err.Error()),
+		err:   err,
+	}
+}
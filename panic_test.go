@@ -0,0 +1,174 @@
+package errors
+
+import (
+	//lint:ignore faillint Custom errors package tests need to import standard library errors.
+	stderrors "errors"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestGuard(t *testing.T) {
+	err := Guard(func() error {
+		panic("boom")
+	})
+	if err == nil || err.Error() != "panic: boom" {
+		t.Fatalf("expected the panic to be converted into an error, got %v", err)
+	}
+	reg := regexp.MustCompile(`^panic: boom[ \n]+> github\.com\/mawngo\/go-errors\.TestGuard\.func1	.*\/go-errors\/panic_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the stacktrace to point at the panic site, got:\n%+v", err)
+	}
+
+	err = Guard(func() error {
+		var m map[string]int
+		m["key"] = 1
+		return nil
+	})
+	var runtimeErr interface{ RuntimeError() }
+	if !stderrors.As(err, &runtimeErr) {
+		t.Fatalf("expected the runtime error panic to be wrapped, got %v", err)
+	}
+
+	err = Guard(func() error {
+		return ErrTest
+	})
+	if err != ErrTest { //nolint:errorlint
+		t.Fatalf("expected the error of fn to be returned unchanged, got %v", err)
+	}
+	if err := Guard(func() error { return nil }); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
+
+// nilDereference panics with a runtime fault.
+func nilDereference() error {
+	var p *int
+	*p = 1
+	return nil
+}
+
+func TestGuardRuntimeFault(t *testing.T) {
+	for i, err := range []error{Guard(nilDereference), recovering(nilDereference)} {
+		frames := GetFrames(err)
+		if len(frames) == 0 || frames[0].Function != "github.com/mawngo/go-errors.nilDereference" {
+			t.Fatalf("case %d: expected the stacktrace to start at the faulting function, got:\n%+v", i, err)
+		}
+		if label := OriginLabel(err); !regexp.MustCompile(`^go-errors\.nilDereference:\d+$`).MatchString(label) {
+			t.Fatalf("case %d: expected the origin label of the faulting function, got %q", i, label)
+		}
+	}
+}
+
+func recovering(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+func TestRecover(t *testing.T) {
+	err := recovering(func() error {
+		panic("boom")
+	})
+	reg := regexp.MustCompile(`^panic: boom[ \n]+> github\.com\/mawngo\/go-errors\.TestRecover\.func1	.*\/go-errors\/panic_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the stacktrace to point at the panic site, got:\n%+v", err)
+	}
+
+	err = recovering(func() error {
+		panic(ErrTest)
+	})
+	if !Is(err, ErrTest) || err.Error() != "panic: global_defined_error" {
+		t.Fatalf("expected the error panic value to be wrapped, got %v", err)
+	}
+
+	err = recovering(func() error {
+		return ErrTest
+	})
+	if err != ErrTest { //nolint:errorlint
+		t.Fatalf("expected the error to be left untouched, got %v", err)
+	}
+}
+
+func TestMust(t *testing.T) {
+	if v := Must(42, nil); v != 42 {
+		t.Fatalf("expected the value to be returned, got %v", v)
+	}
+	Must0(nil)
+
+	for i, fn := range []func(){
+		func() { Must(0, ErrTest) },
+		func() { Must0(ErrTest) },
+	} {
+		func() {
+			defer func() {
+				r := recover()
+				e, ok := AsPanic(r)
+				if !ok {
+					t.Fatalf("case %d: expected the panic value to be a panic error, got %T", i, r)
+				}
+				if !Is(e, ErrTest) || e.Error() != ErrTest.Error() {
+					t.Fatalf("case %d: expected the error to be wrapped, got %v", i, e)
+				}
+				reg := regexp.MustCompile(`^global_defined_error\n> github\.com\/mawngo\/go-errors\.TestMust\.func\d+	.*\/go-errors\/panic_test\.go:\d+`)
+				if !reg.MatchString(fmt.Sprintf("%+v", e)) {
+					t.Fatalf("case %d: expected the stacktrace to point at the caller of Must, got:\n%+v", i, e)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestAssertf(t *testing.T) {
+	Assertf(true, "%s", msg)
+
+	defer func() {
+		err, ok := AsPanic(recover())
+		if !ok {
+			t.Fatalf("expected a panic error to be raised")
+		}
+		reg := regexp.MustCompile(`^invariant 1 violated\n> github\.com\/mawngo\/go-errors\.TestAssertf	.*\/go-errors\/panic_test\.go:\d+`)
+		if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+			t.Fatalf("expected the stacktrace to point at the caller, got:\n%+v", err)
+		}
+	}()
+	Assertf(false, "invariant %d violated", 1)
+}
+
+func TestAsPanic(t *testing.T) {
+	if _, ok := AsPanic(nil); ok {
+		t.Fatalf("expected nil not to match")
+	}
+	for i, fn := range []func(){
+		func() { panic("boom") },
+		func() { panic(ErrTest) },
+		func() {
+			var m map[string]int
+			m["key"] = 1
+		},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("case %d: expected a panic", i)
+				} else if _, ok := AsPanic(r); ok {
+					t.Fatalf("case %d: expected the panic not to match, got %v", i, r)
+				}
+			}()
+			fn()
+		}()
+	}
+
+	err := Guard(func() error {
+		Must0(ErrTest)
+		return nil
+	})
+	var p *PanicError
+	if !As(err, &p) || !Is(err, ErrTest) {
+		t.Fatalf("expected the recovered panic error to be wrapped, got %v", err)
+	}
+	reg := regexp.MustCompile(`^panic\n> .*\n(?s:.*)global_defined_error\n> github\.com\/mawngo\/go-errors\.TestAsPanic\.func\d+	`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the chain to include the stacktrace of the panic error, got:\n%+v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+)
+
+// basePool holds the errors released by Release, to be reused by NewfPooled.
+var basePool = sync.Pool{
+	New: func() any {
+		return &base{}
+	},
+}
+
+// NewfPooled is like [Newf], but reuses the memory of the errors returned to the pool by [Release],
+// which reduces the allocations of high-throughput code creating many short-lived errors.
+//
+// This is an advanced, opt-in API: the error must not be used in any way after it is released,
+// including by the code it was returned to, and must not be released more than once.
+// Pooled errors cannot be used as sentinels, see [Reanchor].
+// Use [Newf] unless heap profiles show that error allocations matter.
+func NewfPooled(format string, args ...any) error {
+	info := format
+	if len(args) > 0 {
+		info = fmt.Sprintf(format, args...)
+	}
+	b := basePool.Get().(*base)
+	buf := b.stack.pcs
+	if cap(buf) <= MaxStackDepth {
+		// capture requests one more program counter than MaxStackDepth to detect deeper stacks.
+		buf = make([]uintptr, MaxStackDepth+1)
+	}
+	*b = base{
+		info:   info,
+		stack:  newStackTraceBuf(buf, 0, info),
+		err:    nil,
+		pooled: true,
+	}
+	return b
+}
+
+// Release returns an error created by [NewfPooled] to the pool once it is fully consumed,
+// for example logged and dropped. The error must not be used after it is released,
+// see NewfPooled. Other errors, including errors wrapping a pooled error, are ignored.
+func Release(err error) {
+	b, ok := asBase(err)
+	if !ok || !b.pooled {
+		return
+	}
+	*b = base{stack: stacktrace{pcs: b.stack.pcs[:0]}}
+	basePool.Put(b)
+}
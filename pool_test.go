@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestNewfPooled(t *testing.T) {
+	err := NewfPooled("%s %d", msg, 1)
+	reg := regexp.MustCompile(`^test_error_message 1\n> github\.com\/mawngo\/go-errors\.TestNewfPooled	.*\/go-errors\/pool_test\.go:\d+`)
+	if !reg.MatchString(fmt.Sprintf("%+v", err)) {
+		t.Fatalf("expected the stacktrace to point at the caller, got:\n%+v", err)
+	}
+	Release(err)
+
+	err = NewfPooled(msg)
+	if err.Error() != msg || !reg.MatchString(fmt.Sprintf("%+v", Wrapf(err, "test_error_message 1"))) {
+		t.Fatalf("expected a reused error to be reset, got:\n%+v", err)
+	}
+
+	wrapped := Wrapf(ErrTest, wrapper)
+	Release(wrapped)
+	if wrapped.Error() != wrapper+": "+ErrTest.Error() {
+		t.Fatalf("expected errors not created by NewfPooled to be ignored, got %v", wrapped)
+	}
+	Release(nil)
+
+	pooled := NewfPooled(msg)
+	reanchored := Reanchor(pooled)
+	if b, _ := asBase(reanchored); b.pooled {
+		t.Fatalf("expected a reanchored copy not to be released")
+	}
+	if Is(reanchored, pooled) {
+		t.Fatalf("expected a reanchored copy not to link to a pooled error")
+	}
+	Release(pooled)
+	if Is(reanchored, NewfPooled(msg)) {
+		t.Fatalf("expected a reanchored copy not to match an error reusing the released memory")
+	}
+	if reanchored.Error() != msg {
+		t.Fatalf("expected the message to be kept, got %q", reanchored.Error())
+	}
+}
+
+func BenchmarkNewfPooled(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		Release(NewfPooled(msg))
+	}
+}
@@ -0,0 +1,55 @@
+package errors
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// LogStack controls whether the stacktrace is included when an error created by this package
+// is logged with [log/slog]. Set it to false to keep log lines short.
+var LogStack = true
+
+// LogValue implements the [slog.LogValuer] interface.
+// The error is logged as a group with its "msg", its "cause" message, its "created_at" time (see [CaptureTime]),
+// its "stack" (see [LogStack]) and the metadata and fields attached to the chain.
+func (b *base) LogValue() slog.Value {
+	return logValue(b)
+}
+
+// LogValue implements the [slog.LogValuer] interface.
+func (v *withValue) LogValue() slog.Value {
+	return logValue(v)
+}
+
+// logValue returns the [slog.Value] of an error chain.
+func logValue(err error) slog.Value {
+	var attrs []slog.Attr
+	if e, _, ok := findBase(err, MaxChainDepth); ok {
+		attrs = append(attrs, slog.String("msg", e.message()))
+		if e.err != nil {
+			attrs = append(attrs, slog.String("cause", e.err.Error()))
+		}
+		if !e.stack.created.IsZero() {
+			attrs = append(attrs, slog.Time("created_at", e.stack.created))
+		}
+		if frames := e.stack.frames(); LogStack && len(frames) > 0 {
+			stack := make([]string, 0, len(frames))
+			for _, frame := range frames {
+				stack = append(stack, frame.Function+" "+frame.File+":"+strconv.Itoa(frame.Line))
+			}
+			attrs = append(attrs, slog.Any("stack", stack))
+		}
+	} else {
+		attrs = append(attrs, slog.String("msg", err.Error()))
+	}
+
+	seen := make(map[metaKey]bool)
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if v, ok := asValue(err); ok && !seen[v.key] {
+			seen[v.key] = true
+			attrs = append(attrs, slog.Any(string(v.key), v.value))
+		}
+		err = unwrapChain(err)
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,45 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	err := WithRequestID(Wrapf(ErrTest, wrapper), "req-1")
+	logger.Error("failed", "err", err)
+
+	var record struct {
+		Err struct {
+			Msg       string   `json:"msg"`
+			Cause     string   `json:"cause"`
+			Stack     []string `json:"stack"`
+			RequestID string   `json:"request_id"`
+		} `json:"err"`
+	}
+	if e := json.Unmarshal(buf.Bytes(), &record); e != nil {
+		t.Fatalf("expected valid JSON, got %v: %s", e, buf.String())
+	}
+	if record.Err.Msg != wrapper || record.Err.Cause != ErrTest.Error() || record.Err.RequestID != "req-1" {
+		t.Fatalf("expected the error attributes, got %s", buf.String())
+	}
+	if len(record.Err.Stack) == 0 || !strings.HasPrefix(record.Err.Stack[0], "github.com/mawngo/go-errors.TestLogValue ") {
+		t.Fatalf("expected the stack attribute, got %s", buf.String())
+	}
+
+	LogStack = false
+	defer func() {
+		LogStack = true
+	}()
+	buf.Reset()
+	logger.Error("failed", "err", Newf(msg))
+	if strings.Contains(buf.String(), "stack") {
+		t.Fatalf("expected no stack attribute, got %s", buf.String())
+	}
+}
@@ -4,56 +4,684 @@
 package errors
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// MaxStackDepth is the maximum number of frames recorded in the stacktrace of newly created errors.
+// When the stack is deeper, the remaining frames are not recorded, and the "%+v" verb prints
+// a "... (more frames)" marker after the recorded frames.
+// A value of zero or less disables the stacktrace capture.
+//
+// MaxStackDepth should be set during program initialization, before errors are created concurrently.
+var MaxStackDepth = 16
+
+// CaptureStack enables the stacktrace capture of newly created errors, it is true by default.
+// When false, errors are created without stacktrace, like [Raw] and plain wrapping do, which saves the CPU
+// and memory of the capture at the cost of losing the origin of errors: the "%+v" verb only prints messages.
+// CaptureStack is read each time an error is created, so it can be flipped at runtime,
+// although flipping it while errors are created concurrently is a data race.
+var CaptureStack = true
+
 // stacktrace holds a snapshot of program counters.
-type stacktrace []uintptr
+// Capturing a stacktrace only records the program counters: they are resolved into frames lazily,
+// when the stacktrace is formatted or inspected, so errors that are created but never printed
+// with "%+v" do not pay for the symbolization.
+type stacktrace struct {
+	// pcs stores the program counters of the recorded frames.
+	pcs []uintptr
+	// truncated reports whether the stack was deeper than MaxStackDepth, so frames were not recorded.
+	truncated bool
+	// goroutine is the ID of the goroutine the stacktrace was captured in, or 0 if not recorded,
+	// see CaptureGoroutineID.
+	goroutine int64
+	// created is the time the stacktrace was captured, or the zero time if not recorded, see CaptureTime.
+	created time.Time
+	// resolved stores the frames of a stacktrace decoded by Decode, whose program counters are unknown.
+	resolved []Frame
+}
+
+// Frame describes a single call site of a stacktrace.
+type Frame struct {
+	// Function is the package path-qualified function name of this call site.
+	Function string
+	// File is the absolute file path of this call site.
+	File string
+	// Line is the line number in File of this call site.
+	Line int
+}
+
+// Frames is a list of frames, from the innermost call site, that can be formatted independently of an error,
+// see [StackTrace]:
+//
+//	fmt.Printf("%+v", frames)
+//
+// The "%+v" verb prints one line per frame like the "%+v" verb of errors, see [FrameFormat].
+// The "%v" and "%s" verbs print a single line summary like [OneLineStack], without limit on the number of frames.
+type Frames []Frame
+
+// Format implements the [fmt.Formatter] interface.
+func (f Frames) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		var buf strings.Builder
+		for _, frame := range f {
+			writeFrame(&buf, frame, FrameFormat)
+		}
+		_, _ = s.Write([]byte(buf.String()))
+		return
+	}
+	_, _ = s.Write([]byte(f.short()))
+}
 
-// newStackTrace captures a stack trace. It skips first 3 frames to record the
-// snapshot of the stack trace at the origin of a particular error. It tries to
-// record maximum 16 frames (if available).
-func newStackTrace() stacktrace {
-	const stackDepth = 16 // record maximum 16 frames (if available).
+// short returns the file name and line of each frame joined by " <- ".
+func (f Frames) short() string {
+	parts := make([]string, 0, len(f))
+	for _, frame := range f {
+		parts = append(parts, filepath.Base(frame.File)+":"+strconv.Itoa(frame.Line))
+	}
+	return strings.Join(parts, " <- ")
+}
+
+// CaptureGoroutineID enables recording the ID of the goroutine creating errors along with their stacktrace,
+// to debug concurrency issues: the "%+v" verb prints a "goroutine N:" header before each stacktrace.
+// It is false by default, since the ID is parsed from the output of [runtime.Stack], which is slow.
+// When false, it has no cost.
+//
+// CaptureGoroutineID should be set during program initialization, before errors are created concurrently.
+var CaptureGoroutineID = false
+
+// CaptureTime enables recording the time errors are created along with their stacktrace,
+// for post-mortem debugging, see [CreatedAt]. It is false by default to avoid the cost of [time.Now]
+// in hot paths. When false, it has no cost.
+//
+// CaptureTime should be set during program initialization, before errors are created concurrently.
+var CaptureTime = false
+
+// noStackMatcher holds the function set by SetNoStackMatcher.
+var noStackMatcher atomic.Pointer[func(msg string) bool]
+
+// SetNoStackMatcher sets a function deciding, from the error message only, whether the stacktrace
+// capture should be skipped for a newly created error.
+// This reduces the overhead of high-frequency expected errors, for example:
+//
+//	errors.SetNoStackMatcher(func(msg string) bool {
+//		return strings.Contains(msg, "not found")
+//	})
+//
+// Passing nil removes the matcher.
+func SetNoStackMatcher(matcher func(msg string) bool) {
+	if matcher == nil {
+		noStackMatcher.Store(nil)
+		return
+	}
+	noStackMatcher.Store(&matcher)
+}
+
+// newStackTrace captures a stack trace for an error with the given message. It skips the frames of
+// newStackTrace and of the function calling it to record the snapshot of the stack trace at the origin
+// of a particular error. It tries to record maximum MaxStackDepth frames (if available).
+// No stack trace is captured if the message matches the function set by SetNoStackMatcher.
+func newStackTrace(msg string) stacktrace {
+	return newStackTraceSkip(1, msg)
+}
 
-	pc := make([]uintptr, stackDepth)
-	// using skip=3 for not to count the program counter address of
+// newStackTraceSkip is like newStackTrace, but additionally skips the given number of frames
+// above the function calling newStackTraceSkip.
+func newStackTraceSkip(skip int, msg string) stacktrace {
+	return newStackTraceBuf(nil, max(skip, 0)+1, msg)
+}
+
+// newStackTraceBuf is like newStackTraceSkip, but records the program counters in buf when it is large enough.
+func newStackTraceBuf(buf []uintptr, skip int, msg string) stacktrace {
+	if !CaptureStack {
+		return stacktrace{}
+	}
+	if matcher := noStackMatcher.Load(); matcher != nil && (*matcher)(msg) {
+		return stacktrace{}
+	}
+	// using skip+2 for not to count the program counter address of
 	// 1. the respective function from errors package (eg. errors.New)
-	// 2. newStacktrace itself
-	// 3. the function used in runtime.Callers
-	n := runtime.Callers(3, pc)
+	// 2. newStackTraceBuf itself
+	return capture(buf, max(skip, 0)+2, MaxStackDepth)
+}
+
+// capture records at most depth program counters of the current goroutine stack, skipping the given
+// number of frames, where 0 identifies the caller of capture. A deeper stack is only marked as truncated.
+// The program counters are recorded in buf if its capacity is more than depth, otherwise in a new slice.
+func capture(buf []uintptr, skip, depth int) stacktrace {
+	if depth <= 0 {
+		return stacktrace{}
+	}
+	// one more program counter is requested to detect whether the stack is deeper than depth,
+	// with a single walk of the stack.
+	reused := cap(buf) > depth
+	var pc []uintptr
+	if reused {
+		pc = buf[:depth+1]
+	} else {
+		pc = make([]uintptr, depth+1)
+	}
+	// using skip+2 for not to count the program counter address of
+	// 1. capture itself
+	// 2. the function used in runtime.Callers
+	n := runtime.Callers(skip+2, pc)
+	truncated := n > depth
+	n = min(n, depth)
 
 	// this approach is taken to reduce long term memory footprint (obtained through escape analysis).
 	// We are returning a new slice by re-slicing the pc with the required length and capacity (when the
-	// no of returned callFrames is less that stackDepth). This uses less memory compared to pc[:n] as
+	// no of returned callFrames is less that depth). This uses less memory compared to pc[:n] as
 	// the capacity of new slice is inherited from the parent slice if not specified.
-	return pc[:n:n]
+	// A reused buffer keeps its capacity to be reused again.
+	st := stacktrace{pcs: pc[:n:n], truncated: truncated}
+	if reused {
+		st.pcs = pc[:n]
+	}
+	if CaptureTime {
+		st.created = time.Now()
+	}
+	if CaptureGoroutineID {
+		st.goroutine = goroutineID()
+	}
+	return st
 }
 
+// goroutineID returns the ID of the current goroutine, parsed from the "goroutine N [status]:" header
+// of the output of runtime.Stack. It returns 0 if the ID cannot be parsed.
+func goroutineID() int64 {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header, ok := bytes.CutPrefix(header, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	id, err := strconv.ParseInt(string(header), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// newPanicStackTrace captures the stack trace of the panic being recovered, starting at the panic site.
+// It must be called from the deferred function recovering the panic: the frames of the deferred
+// function and of the runtime panic machinery are skipped.
+// It tries to record maximum MaxStackDepth frames (if available).
+func newPanicStackTrace() stacktrace {
+	if !CaptureStack {
+		return stacktrace{}
+	}
+	// the deferred function and the runtime panic functions are on top of the panic site,
+	// look for the panic function to know how many frames to skip.
+	var pc [32]uintptr
+	n := runtime.Callers(2, pc[:])
+	for i, p := range pc[:n] {
+		if fn := runtime.FuncForPC(p - 1); fn == nil || fn.Name() != "runtime.gopanic" {
+			continue
+		}
+		// runtime faults, such as a nil dereference, panic through runtime functions
+		// like runtime.sigpanic and runtime.panicmem, which are skipped too.
+		i++
+		for i < n {
+			if fn := runtime.FuncForPC(pc[i] - 1); fn == nil || !strings.HasPrefix(fn.Name(), "runtime.") {
+				break
+			}
+			i++
+		}
+		// pc[0] is the deferred function, which is skipped with 1.
+		return capture(nil, i+1, MaxStackDepth)
+	}
+	return capture(nil, 1, MaxStackDepth)
+}
+
+// TrimFramePrefixes lists function name prefixes, such as "runtime." or "testing.", of the trailing frames
+// that are dropped when printing stacktraces with the "%+v" verb. At least one frame is always printed.
+// It is empty by default.
+//
+// TrimFramePrefixes should be set during program initialization, before errors are formatted concurrently.
+var TrimFramePrefixes []string
+
 // String implements the fmt.Stringer interface to provide formatted text output.
 func (s stacktrace) String() string {
+	return s.render(FrameFormat, -1)
+}
+
+// render returns the formatted text output of the stacktrace, rendering each frame with format.
+// At most maxFrames frames are rendered, the others are counted in the "more frames" marker,
+// which does not have a count if the stacktrace was truncated by MaxStackDepth.
+// A negative maxFrames renders all frames.
+func (s stacktrace) render(format func(frame Frame) string, maxFrames int) string {
+	includeSource := includeSourceLine.Load()
 	var buf strings.Builder
+	if s.goroutine > 0 {
+		buf.WriteString("goroutine ")
+		buf.WriteString(strconv.FormatInt(s.goroutine, 10))
+		buf.WriteString(":\n")
+	}
+	frames := skipFrames(packageFrames(s.trimmedFrames()))
+	more := 0
+	if maxFrames >= 0 && len(frames) > maxFrames {
+		more = len(frames) - maxFrames
+		frames = frames[:maxFrames]
+	}
+	for _, frame := range frames {
+		writeFrame(&buf, frame, format)
+		if !includeSource {
+			continue
+		}
+		if text, ok := sourceLine(frame.File, frame.Line); ok {
+			buf.WriteString("\t")
+			buf.WriteString(text)
+			buf.WriteString("\n")
+		}
+	}
+	switch {
+	case s.truncated:
+		// the number of frames beyond MaxStackDepth is not known.
+		buf.WriteString("... (more frames)\n")
+	case more > 0:
+		buf.WriteString("... (")
+		buf.WriteString(strconv.Itoa(more))
+		buf.WriteString(" more frames)\n")
+	}
+	return buf.String()
+}
+
+// trimmedFrames resolves the frames of the stacktrace, without the trailing frames matching TrimFramePrefixes.
+func (s stacktrace) trimmedFrames() []Frame {
+	frames := s.frames()
+	if len(TrimFramePrefixes) == 0 || s.truncated {
+		// a truncated stacktrace does not end with the trailing frames.
+		return frames
+	}
+	n := len(frames)
+	for n > 1 && hasAnyPrefix(frames[n-1].Function, TrimFramePrefixes) {
+		n--
+	}
+	return frames[:n]
+}
+
+// StackPackagePrefix, when not empty, restricts the frames printed by the "%+v" verb to the functions
+// whose package path-qualified name begins with it, such as "github.com/myorg/", to focus stacktraces
+// on the code of a project. If no frame of a stacktrace matches, all its frames are printed.
+// It is empty by default.
+//
+// StackPackagePrefix should be set during program initialization, before errors are formatted concurrently.
+var StackPackagePrefix string
+
+// packageFrames returns the frames matching StackPackagePrefix, or all frames if none matches.
+func packageFrames(frames []Frame) []Frame {
+	if StackPackagePrefix == "" {
+		return frames
+	}
+	matching := make([]Frame, 0, len(frames))
+	for _, frame := range frames {
+		if strings.HasPrefix(frame.Function, StackPackagePrefix) {
+			matching = append(matching, frame)
+		}
+	}
+	if len(matching) == 0 {
+		return frames
+	}
+	return matching
+}
+
+// StackSkipFunc, when not nil, omits the frames for which it reports true from the stacktraces printed
+// by the "%+v" verb, for example to hide the internal dispatch frames of a framework by function name,
+// however many there are. It applies after [TrimFramePrefixes] and [StackPackagePrefix].
+// If it omits every frame of a stacktrace, all its frames are printed. It is nil by default.
+//
+// StackSkipFunc should be set during program initialization, before errors are formatted concurrently.
+var StackSkipFunc func(frame Frame) bool
+
+// skipFrames returns the frames not omitted by StackSkipFunc, or all frames if it omits every frame.
+func skipFrames(frames []Frame) []Frame {
+	if StackSkipFunc == nil {
+		return frames
+	}
+	kept := make([]Frame, 0, len(frames))
+	for _, frame := range frames {
+		if !StackSkipFunc(frame) {
+			kept = append(kept, frame)
+		}
+	}
+	if len(kept) == 0 {
+		return frames
+	}
+	return kept
+}
+
+// hasAnyPrefix reports whether s begins with any of the prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// includeSourceLine holds the mode set by SetIncludeSourceLine.
+	includeSourceLine atomic.Bool
+	// sourceFiles caches the lines of the source files read by sourceLine.
+	sourceFiles sync.Map
+)
 
+// SetIncludeSourceLine enables or disables printing the source code line under each frame
+// of the stacktrace when an error is formatted with the "%+v" verb.
+// This is a best-effort, development oriented option: frames whose source file cannot be read,
+// for example in production where sources are absent, are printed without their source line.
+// Read source files are cached for the lifetime of the program.
+func SetIncludeSourceLine(include bool) {
+	includeSourceLine.Store(include)
+}
+
+// sourceLine returns the trimmed text of the given line of a source file.
+func sourceLine(file string, line int) (string, bool) {
+	cached, ok := sourceFiles.Load(file)
+	if !ok {
+		var lines []string
+		if content, err := os.ReadFile(file); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		cached, _ = sourceFiles.LoadOrStore(file, lines)
+	}
+	lines := cached.([]string)
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+	return strings.TrimSpace(lines[line-1]), true
+}
+
+// FrameFormat renders a single frame line of the stacktraces printed by the "%+v" verb, without the trailing newline.
+// The default layout is "> <function>\t<file>:<line>", for example:
+//
+//	> testing.tRunner	/home/go/go1.17.8/src/testing/testing.go:1259
+//
+// FrameFormat should be set during program initialization, before errors are formatted concurrently.
+// A nil FrameFormat uses the default layout.
+var FrameFormat = formatFrame
+
+// ShortFilePaths, when enabled, trims the file paths of the frames printed with the default layout
+// of [FrameFormat] to their directory and file name, such as "errors/errors.go", instead of the
+// absolute paths of the build machine. It is disabled by default.
+//
+// ShortFilePaths should be set during program initialization, before errors are formatted concurrently.
+var ShortFilePaths bool
+
+// formatFrame renders a frame with the default layout of [FrameFormat].
+func formatFrame(frame Frame) string {
+	file := frame.File
+	if ShortFilePaths {
+		file = shortFilePath(file)
+	}
+	return "> " + frame.Function + "\t" + file + ":" + strconv.Itoa(frame.Line)
+}
+
+// shortFilePath returns the last directory and the name of a file path.
+// The file paths of frames always use forward slashes.
+func shortFilePath(file string) string {
+	slash := strings.LastIndex(file, "/")
+	if slash < 0 {
+		return file
+	}
+	if dir := strings.LastIndex(file[:slash], "/"); dir >= 0 {
+		return file[dir+1:]
+	}
+	return file
+}
+
+// writeFrame writes a single frame line rendered with format to buf.
+// A nil format uses the default layout of [FrameFormat].
+func writeFrame(buf *strings.Builder, frame Frame, format func(frame Frame) string) {
+	if format == nil {
+		format = formatFrame
+	}
+	buf.WriteString(format(frame))
+	buf.WriteString("\n")
+}
+
+// StackTrace returns the frames of the stacktrace of the first error in the chain carrying one.
+// It returns false if no error in the chain carries a stacktrace.
+//
+// The raw program counters are available through the StackTrace method of the error,
+// for example to resolve them with [runtime.CallersFrames]:
+//
+//	var st interface{ StackTrace() []uintptr }
+//	if errors.As(err, &st) {
+//		frames := runtime.CallersFrames(st.StackTrace())
+//	}
+func StackTrace(err error) (Frames, bool) {
+	st, ok := nearestStack(err)
+	if !ok {
+		return nil, false
+	}
+	return st.frames(), true
+}
+
+// GetFrames returns the frames of the stacktrace of the first error in the chain carrying one,
+// for example to send them to an error tracking service.
+// It returns nil if no error in the chain carries a stacktrace.
+func GetFrames(err error) []Frame {
+	st, ok := nearestStack(err)
+	if !ok {
+		return nil
+	}
+	return st.frames()
+}
+
+// empty reports whether the stacktrace has no frames, neither captured nor decoded.
+func (s stacktrace) empty() bool {
+	return s.len() == 0
+}
+
+// len returns the number of frames of the stacktrace, captured or decoded.
+func (s stacktrace) len() int {
+	if len(s.pcs) == 0 {
+		return len(s.resolved)
+	}
+	return len(s.pcs)
+}
+
+// frames resolves the program counters of the stacktrace into frames, or returns the decoded frames if there are none.
+func (s stacktrace) frames() []Frame {
+	if len(s.pcs) == 0 {
+		return s.resolved
+	}
+	frames := make([]Frame, 0, len(s.pcs))
 	// CallersFrames takes the slice of Program Counter addresses returned by Callers to
 	// retrieve function/file/line information.
-	cf := runtime.CallersFrames(s)
+	cf := runtime.CallersFrames(s.pcs)
 	for {
 		// more indicates if the next call will be successful or not.
 		frame, more := cf.Next()
-		// used formatting scheme <`>`space><function name><tab><filepath><:><line><newline> for example:
-		// > testing.tRunner	/home/go/go1.17.8/src/testing/testing.go:1259
-		buf.WriteString("> ")
-		buf.WriteString(frame.Func.Name())
-		buf.WriteString("\t")
-		buf.WriteString(frame.File)
-		buf.WriteString(":")
-		buf.WriteString(strconv.Itoa(frame.Line))
-		buf.WriteString("\n")
+		frames = append(frames, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
 		if !more {
 			break
 		}
 	}
-	return buf.String()
+	return frames
+}
+
+// commonSuffix returns the number of trailing frames shared by s and other.
+func (s stacktrace) commonSuffix(other stacktrace) int {
+	n := 0
+	for n < len(s.pcs) && n < len(other.pcs) && s.pcs[len(s.pcs)-1-n] == other.pcs[len(other.pcs)-1-n] {
+		n++
+	}
+	return n
+}
+
+// top resolves the first frame of the stacktrace, which must not be empty.
+func (s stacktrace) top() Frame {
+	if len(s.pcs) == 0 {
+		return s.resolved[0]
+	}
+	frame, _ := runtime.CallersFrames(s.pcs[:1]).Next()
+	return Frame{Function: frame.Function, File: frame.File, Line: frame.Line}
+}
+
+// Entry returns the frame where a foreign error entered this package: the top frame of the deepest
+// error created by this package that directly wraps an error not created by this package.
+// This pinpoints the boundary where an error coming from another library was adopted.
+//
+// Entry returns false if no such error exists in the chain or if it has no stacktrace.
+func Entry(err error) (Frame, bool) {
+	var entry *base
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if e, ok := asBase(err); ok && e.err != nil && isForeign(e.err) {
+			entry = e
+		}
+		err = unwrapChain(err)
+	}
+	if entry == nil || entry.stack.empty() {
+		return Frame{}, false
+	}
+	return entry.stack.top(), true
+}
+
+// isForeign reports whether err was not created by this package,
+// looking through the layers that only decorate an error, such as the metadata attached by [WithCode].
+func isForeign(err error) bool {
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		next, ok := unwrapLayer(err)
+		if !ok {
+			_, ok := asBase(err)
+			return !ok
+		}
+		err = next
+	}
+	return false
+}
+
+// OriginLabel returns a low-cardinality label of the origin of the error, suitable for metrics labels:
+// the package-qualified function and line of the call site where the deepest error carrying a stacktrace
+// was created, for example "http.(*Server).Serve:3285".
+// It returns an empty string if no error in the chain carries a stacktrace.
+func OriginLabel(err error) string {
+	var origin stacktrace
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if e, ok := asBase(err); ok && !e.stack.empty() {
+			origin = e.stack
+		}
+		err = unwrapChain(err)
+	}
+	if origin.empty() {
+		return ""
+	}
+	frame := origin.top()
+	function := frame.Function
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+	return function + ":" + strconv.Itoa(frame.Line)
+}
+
+// OneLineStackFrames is the maximum number of frames rendered by [OneLineStack].
+var OneLineStackFrames = 3
+
+// OneLineStack returns a compact single-line summary of the stacktrace of the first error in the chain
+// carrying one, suitable for compact logs, for example "file.go:12 <- other.go:44 <- main.go:9".
+// At most OneLineStackFrames frames are rendered, and the package paths are omitted.
+// It returns an empty string if no error in the chain carries a stacktrace.
+func OneLineStack(err error) string {
+	st, ok := nearestStack(err)
+	if !ok {
+		return ""
+	}
+	frames := Frames(st.frames())
+	if len(frames) > OneLineStackFrames {
+		frames = frames[:max(OneLineStackFrames, 0)]
+	}
+	return frames.short()
+}
+
+// StackContains reports whether a frame of the stacktrace of the first error in the chain carrying one
+// has a function name containing funcName, for example to assert the origin of an error in tests:
+//
+//	errors.StackContains(err, "store.(*DB).Get")
+//
+// It returns false if no error in the chain carries a stacktrace.
+func StackContains(err error, funcName string) bool {
+	st, ok := nearestStack(err)
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(st.frames(), func(frame Frame) bool {
+		return strings.Contains(frame.Function, funcName)
+	})
+}
+
+// nearestStack returns the stacktrace of the first error in the chain carrying one.
+func nearestStack(err error) (stacktrace, bool) {
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if e, ok := asBase(err); ok && !e.stack.empty() {
+			return e.stack, true
+		}
+		err = unwrapChain(err)
+	}
+	return stacktrace{}, false
+}
+
+// CreatedAt returns the time the innermost error of the chain recording one was created,
+// see [CaptureTime]. It returns false if no error of the chain recorded its creation time.
+func CreatedAt(err error) (time.Time, bool) {
+	var created time.Time
+	for depth := 0; err != nil && depth < MaxChainDepth; depth++ {
+		if e, ok := asBase(err); ok && !e.stack.created.IsZero() {
+			created = e.stack.created
+		}
+		err = unwrapChain(err)
+	}
+	return created, !created.IsZero()
+}
+
+// Here returns the location of a caller, formatted as the file name and line like [OneLineStack] does,
+// for example "main.go:42", to get locations consistent with stacktraces without creating an error.
+// The argument skip is the number of frames to skip: 0 identifies the caller of Here,
+// 1 the caller of that caller, and so on.
+// It returns an empty string if there is no such caller.
+func Here(skip int) string {
+	var pc [1]uintptr
+	// using skip+2 for not to count the program counter address of
+	// 1. Here itself
+	// 2. the function used in runtime.Callers
+	n := runtime.Callers(max(skip, 0)+2, pc[:])
+	return Frames(stacktrace{pcs: pc[:n]}.frames()).short()
+}
+
+// SameOrigin reports whether a and b carry the same stacktrace once the top ignoreTop frames
+// of each are skipped. The stacktrace of the first error carrying one in each chain is compared.
+// This is useful to group errors that share a deeper call path but were wrapped by different helpers.
+//
+// SameOrigin returns false if either error has no stacktrace or no frames are left to compare.
+func SameOrigin(a, b error, ignoreTop int) bool {
+	sa, ok := nearestStack(a)
+	if !ok {
+		return false
+	}
+	sb, ok := nearestStack(b)
+	if !ok {
+		return false
+	}
+	if len(sa.pcs) == 0 || len(sb.pcs) == 0 {
+		// a decoded stacktrace has no program counters, its frames are compared instead.
+		fa, fb := sa.frames(), sb.frames()
+		fa, fb = fa[min(max(ignoreTop, 0), len(fa)):], fb[min(max(ignoreTop, 0), len(fb)):]
+		return len(fa) > 0 && slices.Equal(fa, fb)
+	}
+	pa, pb := sa.pcs[min(max(ignoreTop, 0), len(sa.pcs)):], sb.pcs[min(max(ignoreTop, 0), len(sb.pcs)):]
+	return len(pa) > 0 && slices.Equal(pa, pb)
 }
@@ -9,7 +9,7 @@
 )
 
 func caller() stacktrace {
-	return newStackTrace()
+	return newStackTrace("")
 }
 
 func TestStacktraceOutput(t *testing.T) {
@@ -24,7 +24,131 @@ func TestStacktraceProgramCounterLen(t *testing.T) {
 	st := caller()
 	output := st.String()
 	lines := len(strings.Split(strings.TrimSuffix(output, "\n"), "\n"))
-	if len(st) != lines {
-		t.Fatalf("output lines vs program counter size mismatch: program counter size %v, output lines %v", len(st), lines)
+	if len(st.pcs) != lines {
+		t.Fatalf("output lines vs program counter size mismatch: program counter size %v, output lines %v", len(st.pcs), lines)
+	}
+}
+
+func wrapA(err error) error {
+	return Wrapf(err, "wrapped by a")
+}
+
+func wrapB(err error) error {
+	return Wrapf(err, "wrapped by b")
+}
+
+func deep(wrap func(error) error) error {
+	return wrap(ErrTest)
+}
+
+func TestSameOrigin(t *testing.T) {
+	errs := make([]error, 0, 2)
+	for _, wrap := range []func(error) error{wrapA, wrapB} {
+		errs = append(errs, deep(wrap))
+	}
+	if SameOrigin(errs[0], errs[1], 0) {
+		t.Fatalf("expected different wrappers to produce different stacks")
+	}
+	if !SameOrigin(errs[0], errs[1], 1) {
+		t.Fatalf("expected errors sharing a deeper origin to match after ignoring the top frame")
+	}
+	if SameOrigin(errs[0], Newf(msg), 1) {
+		t.Fatalf("expected errors with different origins not to match")
+	}
+	if !SameOrigin(WrapfNoStack(errs[0], wrapper), errs[0], 0) {
+		t.Fatalf("expected the nearest stacktraces to be compared")
+	}
+	if SameOrigin(ErrTest, ErrTest, 0) {
+		t.Fatalf("expected errors without stacktrace not to match")
+	}
+}
+
+func TestSetNoStackMatcher(t *testing.T) {
+	SetNoStackMatcher(func(msg string) bool {
+		return strings.Contains(msg, "not found")
+	})
+	defer SetNoStackMatcher(nil)
+
+	if st, _ := nearestStack(Newf("user %d not found", 1)); len(st.pcs) != 0 {
+		t.Fatalf("expected no frames for a matching message, got %d", len(st.pcs))
+	}
+	if st, _ := nearestStack(Wrapf(ErrTest, "lookup failed")); len(st.pcs) == 0 {
+		t.Fatalf("expected frames for a non matching message")
+	}
+}
+
+func TestStacktraceEmptyOutput(t *testing.T) {
+	if out := (stacktrace{}).String(); out != "" {
+		t.Fatalf("expected empty output for an empty stacktrace, got %q", out)
+	}
+}
+
+func TestSetIncludeSourceLine(t *testing.T) {
+	SetIncludeSourceLine(true)
+	defer SetIncludeSourceLine(false)
+
+	output := caller().String()
+	expected := "\n\toutput := caller().String()\n"
+	if !strings.Contains(output, expected) {
+		t.Fatalf("expected the source line under its frame, received stacktrace: \n%v", output)
+	}
+}
+
+func recurse(depth int) stacktrace {
+	if depth == 0 {
+		return caller()
+	}
+	return recurse(depth - 1)
+}
+
+func TestMaxStackDepth(t *testing.T) {
+	defer func(depth int) {
+		MaxStackDepth = depth
+	}(MaxStackDepth)
+
+	MaxStackDepth = 128
+	full := recurse(40)
+	if full.truncated {
+		t.Fatalf("expected every frame to be recorded")
+	}
+
+	MaxStackDepth = 4
+	st := recurse(40)
+	if len(st.pcs) != 4 {
+		t.Fatalf("expected 4 recorded frames, got %d", len(st.pcs))
+	}
+	if !st.truncated {
+		t.Fatalf("expected the stacktrace to be truncated")
+	}
+	output := st.String()
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) != 5 || lines[4] != "... (more frames)" {
+		t.Fatalf("expected a truncation marker, received stacktrace: \n%v", output)
+	}
+
+	MaxStackDepth = 0
+	if st := caller(); len(st.pcs) != 0 || st.String() != "" {
+		t.Fatalf("expected no frames when the depth is zero")
+	}
+}
+
+func TestTrimFramePrefixes(t *testing.T) {
+	defer func() {
+		TrimFramePrefixes = nil
+	}()
+
+	TrimFramePrefixes = []string{"runtime.", "testing."}
+	output := caller().String()
+	if strings.Contains(output, "runtime.goexit") || strings.Contains(output, "testing.tRunner") {
+		t.Fatalf("expected the trailing frames to be trimmed, received stacktrace: \n%v", output)
+	}
+	if !strings.Contains(output, "go-errors.TestTrimFramePrefixes") {
+		t.Fatalf("expected the test frame to be kept, received stacktrace: \n%v", output)
+	}
+
+	TrimFramePrefixes = []string{""}
+	output = caller().String()
+	if lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n"); len(lines) != 1 {
+		t.Fatalf("expected at least one frame to be kept, received stacktrace: \n%v", output)
 	}
 }